@@ -0,0 +1,363 @@
+package serum
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+This file contains a small CBOR (RFC 8949) codec for the Serum data model.
+
+Only what's needed for Serum is implemented: the error is a map with text keys,
+holding text strings, a map of text strings (for details, in order), and nested maps (for causes).
+The layout mirrors the JSON serial form exactly: the same keys, the same "cause" versus "causes" rule,
+and the details map keeps its order (CBOR maps are ordered on the wire, so this costs nothing).
+
+On decoding, unrecognized map entries are skipped, whatever their type.
+Indefinite-length items are not supported, since encoders producing Serum errors have no reason to use them.
+Extensions (see Data) are a JSON-specific concept and are not included.
+*/
+
+const (
+	cborMajorUint  = 0
+	cborMajorNint  = 1
+	cborMajorBytes = 2
+	cborMajorText  = 3
+	cborMajorArray = 4
+	cborMajorMap   = 5
+	cborMajorTag   = 6
+	cborMajorOther = 7
+)
+
+// cborMaxDepth bounds nesting when decoding, so that hostile input can't exhaust the stack.
+const cborMaxDepth = 1000
+
+// ToCBOR is a helper function to turn any error into CBOR.
+// It follows the same rules as ToJSON (including for non-Serum errors), and produces the same data, just in CBOR form.
+//
+// Errors:
+//
+//   - none in practice; the error return is present for symmetry with ToJSON.
+func ToCBOR(err error) ([]byte, error) {
+	return AppendCBOR(nil, err), nil
+}
+
+// AppendCBOR is like ToCBOR, but appends to the given byte slice, and returns the extended slice.
+// If the error is nil, a CBOR null is appended.
+func AppendCBOR(dst []byte, err error) []byte {
+	if err == nil {
+		return append(dst, cborNull)
+	}
+	code := Code(err)
+	msg := serialMessage(err)
+	details := Details(err)
+	causes := liveCauses(err)
+
+	n := 1
+	if msg != "" {
+		n++
+	}
+	if details != nil {
+		n++
+	}
+	if len(causes) > 0 {
+		n++
+	}
+	dst = appendCBORHead(dst, cborMajorMap, uint64(n))
+	dst = appendCBORText(dst, "code")
+	dst = appendCBORText(dst, code)
+	if msg != "" {
+		dst = appendCBORText(dst, "message")
+		dst = appendCBORText(dst, msg)
+	}
+	if details != nil {
+		dst = appendCBORText(dst, "details")
+		dst = appendCBORHead(dst, cborMajorMap, uint64(len(details)))
+		for _, ent := range details {
+			dst = appendCBORText(dst, ent[0])
+			dst = appendCBORText(dst, ent[1])
+		}
+	}
+	switch len(causes) {
+	case 0:
+		// Nothing to add.
+	case 1:
+		dst = appendCBORText(dst, "cause")
+		dst = AppendCBOR(dst, causes[0])
+	default:
+		dst = appendCBORText(dst, "causes")
+		dst = appendCBORHead(dst, cborMajorArray, uint64(len(causes)))
+		for _, cause := range causes {
+			dst = AppendCBOR(dst, cause)
+		}
+	}
+	return dst
+}
+
+func appendCBORHead(dst []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(dst, major<<5|byte(n))
+	case n <= 0xff:
+		return append(dst, major<<5|24, byte(n))
+	case n <= 0xffff:
+		return append(dst, major<<5|25, byte(n>>8), byte(n))
+	case n <= 0xffffffff:
+		return append(dst, major<<5|26, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	default:
+		return append(dst, major<<5|27, byte(n>>56), byte(n>>48), byte(n>>40), byte(n>>32), byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+}
+
+func appendCBORText(dst []byte, s string) []byte {
+	if !utf8.ValidString(s) { // CBOR text must be valid UTF-8.
+		s = replaceInvalidUTF8(s)
+	}
+	dst = appendCBORHead(dst, cborMajorText, uint64(len(s)))
+	return append(dst, s...)
+}
+
+// replaceInvalidUTF8 replaces each byte of invalid UTF-8 with U+FFFD, as encoding/json does
+// (in contrast to strings.ToValidUTF8, which replaces each run of invalid bytes only once).
+func replaceInvalidUTF8(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s) + 2*utf8.UTFMax)
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			sb.WriteString("\uFFFD")
+		} else {
+			sb.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return sb.String()
+}
+
+// cborNull is the encoding of null, which AppendCBOR produces for a nil error.
+const cborNull = cborMajorOther<<5 | 22
+
+// MarshalCBOR produces the CBOR form of the error, as per ToCBOR.
+func (e *ErrorValue) MarshalCBOR() ([]byte, error) {
+	return ToCBOR(e)
+}
+
+// UnmarshalCBOR parses the CBOR form of an error, as produced by ToCBOR.
+//
+// Errors:
+//
+//   - serum-error-cbor-invalid -- if the input is not well-formed CBOR, or doesn't have the shape of a Serum error.
+func (e *ErrorValue) UnmarshalCBOR(b []byte) error {
+	if len(b) == 1 && b[0] == cborNull { // As with UnmarshalJSON, null leaves the value unchanged.
+		return nil
+	}
+	d := cborDecoder{b: b}
+	data, err := d.decodeError(0)
+	if err != nil {
+		return err
+	}
+	if d.pos != len(d.b) {
+		return d.fail("unexpected data after the end of the error")
+	}
+	e.Data = data
+	return nil
+}
+
+// FromCBOR parses the CBOR form of an error, as produced by ToCBOR.
+// If the input is null (as ToCBOR produces for a nil error), the result is nil, with no error.
+//
+// Errors:
+//
+//   - serum-error-cbor-invalid -- if the input is not well-formed CBOR, or doesn't have the shape of a Serum error.
+func FromCBOR(b []byte) (*ErrorValue, error) {
+	if len(b) == 1 && b[0] == cborNull {
+		return nil, nil
+	}
+	var ev ErrorValue
+	if err := ev.UnmarshalCBOR(b); err != nil {
+		return nil, err
+	}
+	return &ev, nil
+}
+
+type cborDecoder struct {
+	b   []byte
+	pos int
+}
+
+func (d *cborDecoder) fail(reason string) error {
+	return Error("serum-error-cbor-invalid",
+		WithMessageTemplate("deserializing a serum error: invalid cbor at offset {{offset}}: {{reason}}"),
+		WithDetail("offset", strconv.Itoa(d.pos)),
+		WithDetail("reason", reason),
+	)
+}
+
+// head reads the initial byte and argument of an item.
+func (d *cborDecoder) head() (major byte, arg uint64, err error) {
+	if d.pos >= len(d.b) {
+		return 0, 0, d.fail("unexpected end of input")
+	}
+	ib := d.b[d.pos]
+	major, info := ib>>5, ib&0x1f
+	var size int
+	switch {
+	case info < 24:
+		d.pos++
+		return major, uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, 0, d.fail("indefinite-length and reserved items are not supported")
+	}
+	if d.pos+1+size > len(d.b) {
+		return 0, 0, d.fail("unexpected end of input")
+	}
+	for _, b := range d.b[d.pos+1 : d.pos+1+size] {
+		arg = arg<<8 | uint64(b)
+	}
+	d.pos += 1 + size
+	return major, arg, nil
+}
+
+func (d *cborDecoder) expect(want byte, what string) (uint64, error) {
+	major, arg, err := d.head()
+	if err != nil {
+		return 0, err
+	}
+	if major != want {
+		return 0, d.fail("expected " + what)
+	}
+	return arg, nil
+}
+
+func (d *cborDecoder) text() (string, error) {
+	n, err := d.expect(cborMajorText, "a text string")
+	if err != nil {
+		return "", err
+	}
+	if n > uint64(len(d.b)-d.pos) {
+		return "", d.fail("unexpected end of input")
+	}
+	s := string(d.b[d.pos : d.pos+int(n)])
+	d.pos += int(n)
+	return s, nil
+}
+
+// skip skips over one item of any type.
+func (d *cborDecoder) skip(depth int) error {
+	if depth > cborMaxDepth {
+		return d.fail("nested too deeply")
+	}
+	major, arg, err := d.head()
+	if err != nil {
+		return err
+	}
+	switch major {
+	case cborMajorBytes, cborMajorText:
+		if arg > uint64(len(d.b)-d.pos) {
+			return d.fail("unexpected end of input")
+		}
+		d.pos += int(arg)
+	case cborMajorArray, cborMajorMap:
+		n := arg
+		if major == cborMajorMap {
+			n *= 2
+		}
+		for i := uint64(0); i < n; i++ {
+			if err := d.skip(depth + 1); err != nil {
+				return err
+			}
+		}
+	case cborMajorTag:
+		return d.skip(depth + 1)
+	}
+	return nil
+}
+
+func (d *cborDecoder) decodeError(depth int) (data Data, err error) {
+	if depth > cborMaxDepth {
+		return data, d.fail("nested too deeply")
+	}
+	n, err := d.expect(cborMajorMap, "a map")
+	if err != nil {
+		return data, err
+	}
+	var cause []ErrorInterface
+	for i := uint64(0); i < n; i++ {
+		key, err := d.text()
+		if err != nil {
+			return data, err
+		}
+		switch key {
+		case "code":
+			data.Code, err = d.text()
+		case "message":
+			data.Message, err = d.text()
+		case "details":
+			data.Details, err = d.decodeDetails()
+		case "cause":
+			var c Data
+			c, err = d.decodeError(depth + 1)
+			cause = []ErrorInterface{&ErrorValue{c}}
+		case "causes":
+			data.Causes, err = d.decodeCauses(depth + 1)
+		default:
+			err = d.skip(depth + 1)
+		}
+		if err != nil {
+			return data, err
+		}
+	}
+	data.Causes = append(cause, data.Causes...)
+	return data, nil
+}
+
+func (d *cborDecoder) decodeDetails() ([][2]string, error) {
+	n, err := d.expect(cborMajorMap, "a map for details")
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(d.b)-d.pos) { // Each entry is at least two bytes; don't let a bogus length cause a huge allocation.
+		return nil, d.fail("unexpected end of input")
+	}
+	details := make([][2]string, 0, n)
+	for i := uint64(0); i < n; i++ {
+		k, err := d.text()
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.text()
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, [2]string{k, v})
+	}
+	return details, nil
+}
+
+func (d *cborDecoder) decodeCauses(depth int) ([]ErrorInterface, error) {
+	n, err := d.expect(cborMajorArray, "an array for causes")
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(len(d.b)-d.pos) {
+		return nil, d.fail("unexpected end of input")
+	}
+	causes := make([]ErrorInterface, 0, n)
+	for i := uint64(0); i < n; i++ {
+		c, err := d.decodeError(depth)
+		if err != nil {
+			return nil, err
+		}
+		causes = append(causes, &ErrorValue{c})
+	}
+	return causes, nil
+}
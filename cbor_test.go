@@ -0,0 +1,92 @@
+package serum_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/serum-errors/go-serum"
+)
+
+func TestCBOR(t *testing.T) {
+	long := string(bytes.Repeat([]byte("x"), 300))
+	for name, err := range map[string]error{
+		"minimal":        serum.Error("test"),
+		"message":        serum.Error("test", serum.WithMessageLiteral("ünïcödé")),
+		"ordered detail": serum.Error("test", serum.WithDetail("z", "1"), serum.WithDetail("a", long)),
+		"one cause":      serum.Error("test", serum.WithCause(serum.Error("test-a", serum.WithDetail("k", "v")))),
+		"several causes": serum.Error("test", serum.WithCause(serum.Error("test-a")), serum.WithCause(errors.New("plain"))),
+		"non-serum":      errors.New("plain"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			bs, cerr := serum.ToCBOR(err)
+			if cerr != nil {
+				t.Fatal(cerr)
+			}
+			ev, cerr := serum.FromCBOR(bs)
+			if cerr != nil {
+				t.Fatal(cerr)
+			}
+			if a, b := serum.ToJSONString(err), serum.ToJSONString(ev); a != b {
+				t.Fatalf("round trip mismatch:\n\toriginal: %s\n\tdecoded:  %s", a, b)
+			}
+		})
+	}
+
+	t.Run("known encoding", func(t *testing.T) {
+		bs, _ := serum.ToCBOR(serum.Error("e", serum.WithDetail("k", "v")))
+		// {"code": "e", "details": {"k": "v"}}
+		if expect := "a264636f646561656764657461696c73a1616b6176"; hex.EncodeToString(bs) != expect {
+			t.Fatalf("mismatch:\n\tresult: %x\n\texpect: %s", bs, expect)
+		}
+	})
+
+	t.Run("unknown entries are skipped", func(t *testing.T) {
+		// {"x": [1, {"y": h'00'}], "code": "e"}
+		in, _ := hex.DecodeString("a2" + "6178" + "8201a1617941" + "00" + "64636f6465" + "6165")
+		ev, err := serum.FromCBOR(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ev.Code() != "e" {
+			t.Fatalf("unexpected code: %q", ev.Code())
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, in := range []string{
+			"",
+			"a1",                             // map missing its entry
+			"a164636f6465",                   // key with no value
+			"a164636f646501",                 // code is not text
+			"a164636f64656165ff",             // trailing data
+			"bf",                             // indefinite-length map
+			"a164636f64657b0000000000ffffff", // bogus huge length
+		} {
+			bs, _ := hex.DecodeString(in)
+			if _, err := serum.FromCBOR(bs); serum.Code(err) != "serum-error-cbor-invalid" {
+				t.Errorf("%s: expected an invalid cbor error, got %v", in, err)
+			}
+		}
+	})
+	t.Run("invalid utf-8 is replaced as in json", func(t *testing.T) {
+		err := serum.Error("test", serum.WithMessageLiteral("a\xff\xfeb"), serum.WithDetail("k\xff", "\xc3"))
+		bs, _ := serum.ToCBOR(err)
+		ev, cerr := serum.FromCBOR(bs)
+		if cerr != nil {
+			t.Fatal(cerr)
+		}
+		if a, b := serum.ToJSONString(err), serum.ToJSONString(ev); a != b {
+			t.Fatalf("mismatch with json:\n\tjson: %s\n\tcbor: %s", a, b)
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		bs, _ := serum.ToCBOR(nil)
+		ev, err := serum.FromCBOR(bs)
+		if err != nil || ev != nil {
+			t.Fatalf("expected nil for null, got %v, %v", ev, err)
+		}
+	})
+}
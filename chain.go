@@ -0,0 +1,129 @@
+package serum
+
+import (
+	"reflect"
+)
+
+// walkDepthLimit bounds how deep Walk will descend.
+// Cycles through comparable error values are detected precisely;
+// this limit is only a backstop for cycles through values that can't be compared (e.g. slice types).
+const walkDepthLimit = 1000
+
+// Walk visits an error and all of its causes, depth first, in order.
+// The visitor function is called with the depth (zero for the error itself, one for its causes, etc)
+// and the error being visited.
+// If the visitor returns false, the walk stops immediately.
+//
+// Both of golang's conventions for unwrapping are supported
+// (`Unwrap() error` and `Unwrap() []error`), as per the Causes function.
+// Nil and typed-nil causes are skipped.
+// Each error value is visited at most once, so cycles in the cause graph will not cause endless loops.
+//
+// Walk returns false if the visitor stopped the walk, and true otherwise.
+func Walk(err error, visitFn func(depth int, err error) bool) bool {
+	if isNil(err) {
+		return true
+	}
+	return walk(err, 0, map[error]struct{}{}, visitFn)
+}
+
+func walk(err error, depth int, seen map[error]struct{}, visitFn func(depth int, err error) bool) bool {
+	if depth > walkDepthLimit {
+		return true
+	}
+	if hashable(err) {
+		if _, ok := seen[err]; ok {
+			return true
+		}
+		seen[err] = struct{}{}
+	}
+	if !visitFn(depth, err) {
+		return false
+	}
+	for _, cause := range Causes(err) {
+		if isNil(cause) {
+			continue
+		}
+		if !walk(cause, depth+1, seen, visitFn) {
+			return false
+		}
+	}
+	return true
+}
+
+// Find returns the first error in the cause chain (including the error itself) which has the given code.
+// The search order is the same as the Walk function.
+//
+// The second return value is false if no error with that code was found.
+func Find(err error, code string) (error, bool) {
+	var found error
+	Walk(err, func(_ int, e error) bool {
+		if Code(e) == code {
+			found = e
+			return false
+		}
+		return true
+	})
+	return found, found != nil
+}
+
+// HasCode returns true if the error, or any error in its cause chain, has any of the given codes.
+func HasCode(err error, codes ...string) bool {
+	var found bool
+	Walk(err, func(_ int, e error) bool {
+		code := Code(e)
+		for _, c := range codes {
+			if code == c {
+				found = true
+				return false
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// Root returns the deepest cause of an error,
+// following the first cause at each step, as per the Cause function.
+// If the error has no cause, it is returned unchanged.
+//
+// Like Walk, Root skips typed-nil causes, and will stop if it detects a cycle.
+func Root(err error) error {
+	if isNil(err) {
+		return err
+	}
+	seen := map[error]struct{}{}
+	for depth := 0; depth < walkDepthLimit; depth++ {
+		if hashable(err) {
+			seen[err] = struct{}{}
+		}
+		cause := firstLiveCause(err)
+		if cause == nil {
+			break
+		}
+		if hashable(cause) {
+			if _, ok := seen[cause]; ok {
+				break
+			}
+		}
+		err = cause
+	}
+	return err
+}
+
+// hashable reports whether an error can be used as a map key.
+// Checking the type isn't enough: a comparable struct type can hold a slice in an interface field,
+// which would panic when hashed.
+func hashable(err error) bool {
+	return reflect.ValueOf(err).Comparable()
+}
+
+// firstLiveCause is like Cause, but skips typed nils.
+func firstLiveCause(err error) error {
+	for _, cause := range Causes(err) {
+		if !isNil(cause) {
+			return cause
+		}
+	}
+	return nil
+}
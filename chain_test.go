@@ -0,0 +1,116 @@
+package serum_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/serum-errors/go-serum"
+)
+
+// cyclicErr is an error whose cause can be set after creation, so we can make cycles.
+type cyclicErr struct {
+	cause error
+}
+
+func (e *cyclicErr) Error() string { return "cyclic" }
+func (e *cyclicErr) Unwrap() error { return e.cause }
+
+// structErr is a comparable struct type, whose interface field may hold an unhashable value.
+type structErr struct {
+	inner interface{}
+}
+
+func (e structErr) Error() string { return "struct" }
+func (e structErr) Unwrap() error {
+	err, _ := e.inner.(error)
+	return err
+}
+
+func TestWalk(t *testing.T) {
+	err := serum.Error("test-top",
+		serum.WithCause(serum.Error("test-a", serum.WithCause(serum.Error("test-a1")))),
+		serum.WithCause(fmt.Errorf("wrap: %w", serum.Error("test-b"))),
+	)
+	var visited []string
+	serum.Walk(err, func(depth int, e error) bool {
+		visited = append(visited, fmt.Sprintf("%d:%s", depth, serum.Code(e)))
+		return true
+	})
+	expect := "[0:test-top 1:test-a 2:test-a1 1:bestguess-golang-fmt-wrapError 2:test-b]"
+	if fmt.Sprint(visited) != expect {
+		t.Fatalf("mismatch:\n\tresult: %v\n\texpect: %s", visited, expect)
+	}
+
+	t.Run("find", func(t *testing.T) {
+		found, ok := serum.Find(err, "test-b")
+		if !ok || serum.Code(found) != "test-b" {
+			t.Fatalf("expected to find test-b, got %v", found)
+		}
+		if _, ok := serum.Find(err, "test-missing"); ok {
+			t.Fatal("should not find missing code")
+		}
+	})
+	t.Run("hascode", func(t *testing.T) {
+		if !serum.HasCode(err, "test-missing", "test-a1") {
+			t.Fatal("should find test-a1")
+		}
+		if serum.HasCode(err, "test-missing") {
+			t.Fatal("should not find missing code")
+		}
+	})
+	t.Run("root", func(t *testing.T) {
+		if root := serum.Root(err); serum.Code(root) != "test-a1" {
+			t.Fatalf("unexpected root: %v", root)
+		}
+		leaf := errors.New("leaf")
+		if serum.Root(leaf) != leaf {
+			t.Fatal("an error without causes should be its own root")
+		}
+	})
+	t.Run("typed nil cause", func(t *testing.T) {
+		var nilCause *cyclicErr
+		e := &cyclicErr{cause: nilCause}
+		if root := serum.Root(e); root != e {
+			t.Fatalf("unexpected root: %v", root)
+		}
+		n := 0
+		serum.Walk(e, func(int, error) bool { n++; return true })
+		if n != 1 {
+			t.Fatalf("expected to visit 1 error, visited %d", n)
+		}
+	})
+	t.Run("cycles", func(t *testing.T) {
+		a := &cyclicErr{}
+		b := &cyclicErr{cause: a}
+		a.cause = b
+		n := 0
+		serum.Walk(a, func(int, error) bool { n++; return true })
+		if n != 2 {
+			t.Fatalf("expected to visit 2 errors, visited %d", n)
+		}
+		if serum.HasCode(a, "test-missing") {
+			t.Fatal("should not find missing code")
+		}
+		if root := serum.Root(a); root != b {
+			t.Fatalf("unexpected root: %v", root)
+		}
+	})
+	t.Run("unhashable values in comparable types", func(t *testing.T) {
+		e := structErr{inner: joinedErr{serum.Error("test-inner")}} // joinedErr (see serum_test.go) is unhashable.
+		n := 0
+		serum.Walk(e, func(int, error) bool { n++; return true })
+		if n != 3 {
+			t.Fatalf("expected to visit 3 errors, visited %d", n)
+		}
+		if !serum.HasCode(e, "test-inner") {
+			t.Fatal("should find the inner code")
+		}
+		if root := serum.Root(e); serum.Code(root) != "test-inner" {
+			t.Fatalf("unexpected root: %v", root)
+		}
+		if root := serum.Root(structErr{inner: []int{}}); root.Error() != "struct" {
+			t.Fatalf("unexpected root: %v", root)
+		}
+	})
+}
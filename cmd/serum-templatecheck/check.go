@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+
+	"github.com/serum-errors/go-serum"
+)
+
+// serumImportPath is the import path of the package whose constructor calls we check.
+const serumImportPath = "github.com/serum-errors/go-serum"
+
+// diagnostic is a single problem found by the checker.
+type diagnostic struct {
+	Pos     token.Pos
+	Message string
+}
+
+// pass holds the state for checking one package's worth of files.
+// It is shaped after the `go/analysis` Pass type, so that this checker could be ported
+// to that framework easily; we just don't want to take on the dependency.
+type pass struct {
+	Fset        *token.FileSet
+	Files       []*ast.File
+	Diagnostics []diagnostic
+}
+
+func (p *pass) reportf(pos token.Pos, format string, args ...interface{}) {
+	p.Diagnostics = append(p.Diagnostics, diagnostic{pos, fmt.Sprintf(format, args...)})
+}
+
+// run inspects every call to `serum.Error(...)` in the files, and reports:
+//
+//   - message templates which refer to a detail key that no WithDetail in the same call supplies;
+//   - message templates which are not well-formed (as per serum.ParseTemplate);
+//   - detail keys supplied more than once in the same call;
+//   - calls which supply more than one message option, marking the ones that are ignored.
+//     (Any message template takes precedence over every message literal, whatever the order;
+//     otherwise, the last one given takes effect.)
+//
+// Only string literals are understood.
+// If a call uses options that can't be understood statically
+// (e.g. a variable holding a WithConstruction, a non-literal detail key, or a spread of a slice),
+// the missing-key check is skipped for that call, since the key could be supplied in a way we can't see.
+func run(p *pass) {
+	for _, file := range p.Files {
+		name := serumImportName(file)
+		if name == "" {
+			continue
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || !isSerumCall(call, name, "Error") {
+				return true
+			}
+			checkErrorCall(p, call, name)
+			return true
+		})
+	}
+}
+
+// serumImportName returns the name the serum package is imported under in the file,
+// or empty string if it isn't imported (or is imported in a way we don't handle, like a dot import).
+func serumImportName(file *ast.File) string {
+	for _, imp := range file.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil || path != serumImportPath {
+			continue
+		}
+		if imp.Name == nil {
+			return "serum"
+		}
+		if imp.Name.Name == "." || imp.Name.Name == "_" {
+			return ""
+		}
+		return imp.Name.Name
+	}
+	return ""
+}
+
+func isSerumCall(call *ast.CallExpr, pkgName, funcName string) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != funcName {
+		return false
+	}
+	id, ok := sel.X.(*ast.Ident)
+	return ok && id.Name == pkgName
+}
+
+// stringLiteral returns the value of an expression if it's a string literal.
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+func checkErrorCall(p *pass, call *ast.CallExpr, name string) {
+	if len(call.Args) < 2 {
+		return
+	}
+	opaque := call.Ellipsis.IsValid() // If there's a spread, we can't see all the options.
+	var template *serum.Template
+	var templatePos token.Pos
+	var literals, templates []token.Pos
+	details := map[string]token.Pos{}
+	for _, arg := range call.Args[1:] {
+		opt, ok := arg.(*ast.CallExpr)
+		if !ok {
+			opaque = true
+			continue
+		}
+		switch {
+		case isSerumCall(opt, name, "WithMessageTemplate"):
+			templates = append(templates, opt.Pos())
+			template = nil // Only the last template takes effect; forget any earlier one.
+			if len(opt.Args) != 1 {
+				continue
+			}
+			s, ok := stringLiteral(opt.Args[0])
+			if !ok {
+				continue
+			}
+			t, err := serum.ParseTemplate(s)
+			if err != nil {
+				hint := ""
+				if serum.Code(err) == "serum-error-template-unknown-process" {
+					hint = " (if it's added with serum.RegisterTemplateProcess, name it with the -processes flag)"
+				}
+				p.reportf(opt.Args[0].Pos(), "message template is not well-formed: %s%s", serum.Message(err), hint)
+				continue
+			}
+			template, templatePos = t, opt.Args[0].Pos()
+		case isSerumCall(opt, name, "WithTemplate"):
+			templates = append(templates, opt.Pos())
+			template = nil // Already checked by ParseTemplate, and we can't see its keys.
+		case isSerumCall(opt, name, "WithMessageLiteral"):
+			literals = append(literals, opt.Pos())
+		case isSerumCall(opt, name, "WithDetail"):
+			if len(opt.Args) != 2 {
+				continue
+			}
+			key, ok := stringLiteral(opt.Args[0])
+			if !ok {
+				opaque = true
+				continue
+			}
+			if _, exists := details[key]; exists {
+				p.reportf(opt.Args[0].Pos(), "detail %q is supplied more than once", key)
+			}
+			details[key] = opt.Args[0].Pos()
+		case isSerumCall(opt, name, "WithCause"):
+			// Fine; nothing to check.
+		default:
+			opaque = true
+		}
+	}
+	// serum.Error applies a template after all other options, whatever the order they're given in,
+	// so any template beats every literal, and otherwise the last literal wins.
+	if len(templates) > 0 {
+		for _, pos := range literals {
+			p.reportf(pos, "message literal is ignored, because a message template is also given")
+		}
+		for _, pos := range templates[:len(templates)-1] {
+			p.reportf(pos, "more than one message template is given; this one is ignored in favor of the last one")
+		}
+	} else if len(literals) > 1 {
+		for _, pos := range literals[:len(literals)-1] {
+			p.reportf(pos, "more than one message literal is given; this one is ignored in favor of the last one")
+		}
+	}
+	if template != nil && !opaque {
+		for _, key := range template.Keys() {
+			if _, ok := details[key]; !ok {
+				p.reportf(templatePos, "message template refers to detail %q, which is not supplied", key)
+			}
+		}
+	}
+}
+
+// allowProcesses makes the named template processes acceptable to serum.ParseTemplate,
+// by registering a placeholder for each one that isn't already known.
+// The checker only needs to know that the processes exist; it never renders any templates.
+//
+// Errors:
+//
+//   - serum-error-template-process-invalid -- if a name is not usable as a process name.
+func allowProcesses(names []string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = r.(error)
+		}
+	}()
+	for _, name := range names {
+		if _, err := serum.ParseTemplate("{{x|" + name + "}}"); err == nil {
+			continue // Already known, perhaps from an earlier call.
+		}
+		if name == "default" || name == "trunc" {
+			continue // Built in, but they need an argument, so the check above can't see them.
+		}
+		serum.RegisterTemplateProcess(name, func(s string) string { return s })
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+const checkSource = `package demo
+
+import (
+	"strconv"
+
+	srm "github.com/serum-errors/go-serum"
+)
+
+func good(id int) error {
+	return srm.Error("demo-error-good",
+		srm.WithMessageTemplate("job {{ID|q}} not found"),
+		srm.WithDetail("ID", strconv.Itoa(id)),
+	)
+}
+
+func missingKey() error {
+	return srm.Error("demo-error-missing",
+		srm.WithMessageTemplate("job {{ID}} in {{queue}}"),
+		srm.WithDetail("ID", "1"),
+	)
+}
+
+func duplicateDetail() error {
+	return srm.Error("demo-error-dup",
+		srm.WithDetail("ID", "1"),
+		srm.WithDetail("ID", "2"),
+	)
+}
+
+func twoMessages() error {
+	return srm.Error("demo-error-twomsg",
+		srm.WithMessageLiteral("first"),
+		srm.WithMessageTemplate("second"),
+	)
+}
+
+func templateThenLiteral() error {
+	return srm.Error("demo-error-tmplfirst",
+		srm.WithMessageTemplate("first"),
+		srm.WithMessageLiteral("second"),
+	)
+}
+
+func twoTemplates() error {
+	return srm.Error("demo-error-twotmpl",
+		srm.WithMessageTemplate("first {{missing}}"),
+		srm.WithMessageTemplate("second"),
+	)
+}
+
+func twoLiterals() error {
+	return srm.Error("demo-error-twolit",
+		srm.WithMessageLiteral("first"),
+		srm.WithMessageLiteral("second"),
+	)
+}
+
+func malformed() error {
+	return srm.Error("demo-error-malformed",
+		srm.WithMessageTemplate("job {{ID"),
+	)
+}
+
+func opaque(more srm.WithConstruction) error {
+	return srm.Error("demo-error-opaque",
+		srm.WithMessageTemplate("job {{ID}}"),
+		more,
+	)
+}
+`
+
+func TestCheck(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "demo.go", checkSource, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &pass{Fset: fset, Files: []*ast.File{f}}
+	run(p)
+	var got []string
+	for _, d := range p.Diagnostics {
+		got = append(got, fmt.Sprintf("%d: %s", fset.Position(d.Pos).Line, d.Message))
+	}
+	expect := []string{
+		`18: message template refers to detail "queue", which is not supplied`,
+		`26: detail "ID" is supplied more than once`,
+		`32: message literal is ignored, because a message template is also given`,
+		`40: message literal is ignored, because a message template is also given`,
+		`46: more than one message template is given; this one is ignored in favor of the last one`,
+		`53: more than one message literal is given; this one is ignored in favor of the last one`,
+		`60: message template is not well-formed: template "job {{ID" has unbalanced delimiters at offset 4`,
+	}
+	if strings.Join(got, "\n") != strings.Join(expect, "\n") {
+		t.Fatalf("mismatch:\nresult:\n\t%s\nexpect:\n\t%s", strings.Join(got, "\n\t"), strings.Join(expect, "\n\t"))
+	}
+}
+
+func TestCheckRegisteredProcesses(t *testing.T) {
+	const src = `package demo
+
+import "github.com/serum-errors/go-serum"
+
+func registered() error {
+	return serum.Error("demo-error-registered",
+		serum.WithMessageTemplate("job {{ID | shout}}"),
+		serum.WithDetail("ID", "1"),
+	)
+}
+
+func unregistered() error {
+	return serum.Error("demo-error-unregistered",
+		serum.WithMessageTemplate("job {{ID | nosuchprocess}}"),
+		serum.WithDetail("ID", "1"),
+	)
+}
+`
+	if err := allowProcesses([]string{"shout", "q", "trunc"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := allowProcesses([]string{"bad name"}); err == nil {
+		t.Fatal("expected an error for an unusable process name")
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "demo.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &pass{Fset: fset, Files: []*ast.File{f}}
+	run(p)
+	var got []string
+	for _, d := range p.Diagnostics {
+		got = append(got, fmt.Sprintf("%d: %s", fset.Position(d.Pos).Line, d.Message))
+	}
+	expect := []string{
+		`14: message template is not well-formed: template "job {{ID | nosuchprocess}}" uses unknown process "nosuchprocess" (if it's added with serum.RegisterTemplateProcess, name it with the -processes flag)`,
+	}
+	if strings.Join(got, "\n") != strings.Join(expect, "\n") {
+		t.Fatalf("mismatch:\nresult:\n\t%s\nexpect:\n\t%s", strings.Join(got, "\n\t"), strings.Join(expect, "\n\t"))
+	}
+}
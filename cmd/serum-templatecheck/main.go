@@ -0,0 +1,139 @@
+/*
+The serum-templatecheck command inspects calls to `serum.Error(...)`
+and reports mistakes in how message templates and details are used together.
+
+It reports:
+
+  - message templates which refer to a detail key that no WithDetail in the same call supplies
+    (these would otherwise show up literally, as "{{x}}", in the produced messages);
+  - message templates which are not well-formed;
+  - detail keys supplied more than once in the same call;
+  - calls which supply more than one message option, marking the ones that are ignored.
+    (Any message template takes precedence over every message literal, whatever the order they're given in;
+    otherwise, the last one given takes effect.)
+
+Usage:
+
+	serum-templatecheck [-processes name,...] [dir ...]
+
+Templates may only use the built-in processes, unless more are named with the -processes flag
+(typically, the ones a program adds with serum.RegisterTemplateProcess).
+
+Each argument is a directory of golang source files.
+A directory ending in "/..." is checked recursively (skipping testdata, vendor, and hidden directories).
+If no arguments are given, "./..." is assumed.
+
+The exit code is 0 if no problems were found, 1 if any problems were found, and 2 if the source could not be read (or the arguments are not valid).
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+func main() {
+	os.Exit(mainWithArgs(os.Args[1:]))
+}
+
+func mainWithArgs(args []string) int {
+	flags := flag.NewFlagSet("serum-templatecheck", flag.ContinueOnError)
+	processes := flags.String("processes", "", "comma-separated names of template processes added with serum.RegisterTemplateProcess")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	args = flags.Args()
+	if *processes != "" {
+		if err := allowProcesses(strings.Split(*processes, ",")); err != nil {
+			fmt.Fprintf(os.Stderr, "serum-templatecheck: %v\n", err)
+			return 2
+		}
+	}
+	if len(args) == 0 {
+		args = []string{"./..."}
+	}
+	var dirs []string
+	for _, arg := range args {
+		if strings.HasSuffix(arg, "/...") {
+			found, err := findDirs(strings.TrimSuffix(arg, "/..."))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "serum-templatecheck: %v\n", err)
+				return 2
+			}
+			dirs = append(dirs, found...)
+		} else {
+			dirs = append(dirs, arg)
+		}
+	}
+	fset := token.NewFileSet()
+	var diags []diagnostic
+	for _, dir := range dirs {
+		files, err := parseDir(fset, dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "serum-templatecheck: %v\n", err)
+			return 2
+		}
+		p := &pass{Fset: fset, Files: files}
+		run(p)
+		diags = append(diags, p.Diagnostics...)
+	}
+	sort.SliceStable(diags, func(i, j int) bool { return diags[i].Pos < diags[j].Pos })
+	for _, d := range diags {
+		fmt.Printf("%s: %s\n", fset.Position(d.Pos), d.Message)
+	}
+	if len(diags) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// findDirs returns the directory and all its subdirectories, skipping those the go tool would also skip.
+func findDirs(root string) ([]string, error) {
+	if root == "" {
+		root = "."
+	}
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if path != root && (name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+			return filepath.SkipDir
+		}
+		dirs = append(dirs, path)
+		return nil
+	})
+	return dirs, err
+}
+
+// parseDir parses every golang source file in a directory (including tests).
+func parseDir(fset *token.FileSet, dir string) ([]*ast.File, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []*ast.File
+	for _, ent := range entries {
+		if ent.IsDir() || !strings.HasSuffix(ent.Name(), ".go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, ent.Name()), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
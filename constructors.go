@@ -13,6 +13,7 @@ import (
 //
 // If a %w verb is used, Errorf will take an error parameter in the args and attach it as "cause",
 // similarly to the behavior of `fmt.Errorf`.
+// If several %w verbs are used, all of those errors are attached as causes.
 // However, if that error is not already a Serum-style error (concretely: if it does not implement ErrorInterface),
 // it will be coerced into one, by use of the Standardize function.
 // (We consider this coersion appropriate to perform immediately,
@@ -35,7 +36,7 @@ func Errorf(ecode string, fmtPattern string, args ...interface{}) error {
 	return &ErrorValue{Data{
 		Code:    ecode,
 		Message: fmtErr.Error(),
-		Cause:   Standardize(Cause(fmtErr)),
+		Causes:  standardizeAll(Causes(fmtErr)),
 	}}
 }
 
@@ -54,10 +55,16 @@ func Errorf(ecode string, fmtPattern string, args ...interface{}) error {
 // the same procedure is followed: a new value will be created,
 // where the code is set to what `serum.Code` returns on the old value; etc.
 // (In practice, this means you'll end up with an ErrorValue that contains a
-// code string that is prefixed with "golang-bestguess-"; etc.)
+// code string that is prefixed with "golang-bestguess-", unless a code resolver
+// has been registered for that kind of error; etc.)
 //
 // If given a value that is already of type *ErrorValue, it is returned unchanged.
 //
+// Otherwise, the given value is kept in the Original field of the new value.
+// This doesn't affect serialization or any of the Serum accessor functions,
+// but it means the original error can still be found with `errors.As` (and `errors.Is`),
+// so the original golang type isn't lost for in-process inspection.
+//
 // This function returns ErrorInterface rather than concretely *ErrorValue,
 // to reduce the chance of creating "untyped nil" problems in practical usage,
 // but it is valid to directly cast the result to *ErrorValue if you wish.
@@ -72,10 +79,26 @@ func Standardize(other error) ErrorInterface {
 		Code:    Code(other),
 		Message: Message(other),
 		Details: Details(other),
-		Cause:   Standardize(Cause(other)),
+		Causes:  standardizeAll(Causes(other)),
+
+		Original: other,
 	}}
 }
 
+// standardizeAll applies Standardize to each error, skipping any nils (including typed nils).
+func standardizeAll(errs []error) []ErrorInterface {
+	if len(errs) == 0 {
+		return nil
+	}
+	res := make([]ErrorInterface, 0, len(errs))
+	for _, err := range errs {
+		if !isNil(err) {
+			res = append(res, Standardize(err))
+		}
+	}
+	return res
+}
+
 // Error is a constructor for new Serum-style error values,
 // supporting use of templated messages, and attachment of details,
 // causes, and the enter suite of Serum features.
@@ -104,7 +127,7 @@ func Error(ecode string, params ...WithConstruction) error {
 		case param.detailKey != "":
 			res.Data.Details = append(res.Data.Details, [2]string{param.detailKey, param.detailValue})
 		case param.cause != nil:
-			res.Data.Cause = param.cause
+			res.Data.Causes = append(res.Data.Causes, param.cause)
 		}
 	}
 	if doLast.msgTemplate != nil {
@@ -125,11 +148,37 @@ func Error(ecode string, params ...WithConstruction) error {
 // then the output will just contain the template syntax and missing label
 // (e.g., "{{x}}" will be emitted as output).
 //
+// Values can be passed through a pipeline of processes, like "{{x | trunc 8 | q}}".
+// The built-in processes are:
+//
+//   - q -- quotes the value, in golang syntax.
+//   - default "text" -- replaces an empty or missing value with the given text.
+//   - trunc N -- truncates the value to at most N characters.
+//   - upper, lower -- changes the case of the value.
+//   - json -- encodes the value as a JSON string (without the HTML escaping that encoding/json does by default).
+//   - path -- takes the last element of a slash-separated path (as per path.Base, so the result is the same on every OS).
+//
+// More processes can be added with RegisterTemplateProcess.
+// Unknown processes don't cause errors either;
+// they're skipped, and a marker is emitted in the output so you can see your typo.
+//
+// A literal "{{" or "}}" can be written by escaping it with a backslash: \{{ or \}}.
+// If you'd like templates to be checked for mistakes in advance, see ParseTemplate and WithTemplate.
+//
 // See the examples of the Error function for complete demonstrations of usage.
 func WithMessageTemplate(tmpl string) WithConstruction {
 	return WithConstruction{msgTemplate: parse(tmpl)}
 }
 
+// WithTemplate is part of the system for constructing an error
+// with the serum.Error function.
+//
+// It is the same as WithMessageTemplate, but uses a template that was
+// already parsed and checked by ParseTemplate.
+func WithTemplate(tmpl *Template) WithConstruction {
+	return WithConstruction{msgTemplate: tmpl.parsed}
+}
+
 // WithMessageLiteral is part of the system for constructing an error
 // with the serum.Error function.
 //
@@ -152,15 +201,18 @@ func WithDetail(key, value string) WithConstruction {
 	return WithConstruction{detailKey: key, detailValue: value}
 }
 
-// WithDetail is part of the system for constructing an error
+// WithCause is part of the system for constructing an error
 // with the serum.Error function.
 // It can accept any golang error value and will attach it as a cause
 // to the newly produced Serum error.
+// It may be used more than once, in which case all the causes are attached, in order.
 //
 // As with Errorf's behavior when attaching causes, if the given error
 // is not already Serum-style error, it will be coerced into one.
 // This may result in a generated error code, which is prefixed with
 // the string "bestguess-golang-" and some type name information.
+// The original error value is still kept, and can be found with `errors.As`;
+// see Standardize for details.
 func WithCause(cause error) WithConstruction {
 	return WithConstruction{cause: Standardize(cause)}
 }
@@ -2,8 +2,10 @@ package serum_test
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
+	"testing"
 
 	"github.com/serum-errors/go-serum"
 )
@@ -58,7 +60,7 @@ func ExampleError() {
 	// 	}
 }
 
-func ExampleErrorWithTemplateQuoting() {
+func ExampleError_withTemplateQuoting() {
 	err := serum.Error("demo-error-withquotes",
 		serum.WithMessageTemplate("message detail {{thedetail|q}} should be quoted"),
 		serum.WithDetail("thedetail", "whee! wow!"),
@@ -82,3 +84,19 @@ func ExampleErrorWithTemplateQuoting() {
 	// 		}
 	// 	}
 }
+
+func TestErrorfMultipleWrap(t *testing.T) {
+	a := serum.Error("test-a")
+	b := serum.Error("test-b")
+	err := serum.Errorf("test", "both %w and %w", a, b)
+	if causes := serum.Causes(err); len(causes) != 2 || causes[0] != a || causes[1] != b {
+		t.Fatalf("unexpected causes: %v", causes)
+	}
+	if s := err.Error(); s != "test: both test-a and test-b: caused by: [test-a; test-b]" {
+		t.Fatalf("unexpected string: %s", s)
+	}
+	joined := serum.Standardize(errors.Join(a, b))
+	if !errors.Is(joined, a) || !errors.Is(joined, b) {
+		t.Fatal("errors.Is should find both joined causes")
+	}
+}
@@ -0,0 +1,138 @@
+package serum
+
+import (
+	"bytes"
+	"sync"
+)
+
+// Decoder rebuilds errors from their serial form, using a registry of constructors
+// to produce user-defined error types for the codes it knows about.
+//
+// Without a Decoder, deserializing an error (e.g. with ErrorValue.UnmarshalJSON) always yields *ErrorValue,
+// which is perfectly usable with the serum accessor functions, and with CodeSentinel and `errors.Is`,
+// but can't be matched with `errors.As` against a program's own error types.
+// A Decoder fixes that: register a constructor for each code that should become a specific golang type,
+// and the decoder will call it (for the error itself, and for every nested cause).
+// Codes with no registered constructor still become *ErrorValue.
+//
+// Most programs can use the package-scope FromJSON and RegisterDecodable functions,
+// which use a default Decoder.  Create a separate Decoder with NewDecoder if a separate registry is needed.
+// (The zero value of Decoder is also ready to use, with no constructors registered.)
+type Decoder struct {
+	// Strict causes the decoder to use ErrorValue.UnmarshalJSONStrict rather than the lenient ErrorValue.UnmarshalJSON.
+	Strict bool
+
+	mu    sync.RWMutex
+	ctors map[string]func(Data) error
+}
+
+// NewDecoder returns a new Decoder with no constructors registered.
+func NewDecoder() *Decoder {
+	return &Decoder{ctors: map[string]func(Data) error{}}
+}
+
+// DefaultDecoder is the Decoder used by the FromJSON and RegisterDecodable functions.
+var DefaultDecoder = NewDecoder()
+
+// Register declares the constructor to use when decoding errors with the given code.
+//
+// The constructor is given the decoded Data (whose Causes have already been decoded in the same way),
+// and should return the rebuilt error.
+// If the constructor returns nil, the decoder falls back to producing an *ErrorValue.
+// If the returned error doesn't implement ErrorInterface, it will be standardized when used as a cause
+// (it remains reachable with `errors.As`; see Standardize).
+//
+// Register is meant to be called at init time.
+// It panics if a constructor is already registered for the code.
+//
+// Errors:
+//
+//   - serum-error-decoder-duplicate -- if a constructor is already registered for the code.
+func (d *Decoder) Register(code string, fn func(Data) error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, exists := d.ctors[code]; exists {
+		panic(Error("serum-error-decoder-duplicate",
+			WithMessageTemplate("a decoding constructor is already registered for error code {{code|q}}"),
+			WithDetail("code", code),
+		))
+	}
+	if d.ctors == nil {
+		d.ctors = map[string]func(Data) error{}
+	}
+	d.ctors[code] = fn
+}
+
+// Decode parses a JSON serial form of an error (as produced by ToJSON),
+// and rebuilds it using the registered constructors.
+//
+// The first return value is the decoded error; the second is any problem with decoding.
+// If the JSON is literally "null", the decoded error is nil.
+//
+// Errors:
+//
+//   - any error from ErrorValue.UnmarshalJSON (or ErrorValue.UnmarshalJSONStrict, if the Strict field is set) -- if the JSON is not a valid Serum error.
+func (d *Decoder) Decode(b []byte) (error, error) {
+	if bytes.Equal(bytes.TrimSpace(b), []byte("null")) {
+		return nil, nil
+	}
+	var ev ErrorValue
+	unmarshal := ev.UnmarshalJSON
+	if d.Strict {
+		unmarshal = ev.UnmarshalJSONStrict
+	}
+	if err := unmarshal(b); err != nil {
+		return nil, err
+	}
+	return d.Rebuild(&ev), nil
+}
+
+// Rebuild converts an *ErrorValue (and all its causes) using the registered constructors.
+// This is the second half of what Decode does; it's useful if the value was deserialized some other way.
+func (d *Decoder) Rebuild(ev *ErrorValue) error {
+	data := ev.Data
+	if len(data.Causes) > 0 {
+		data.Causes = make([]ErrorInterface, 0, len(ev.Data.Causes))
+		for _, cause := range ev.Data.Causes {
+			if ev2, ok := cause.(*ErrorValue); ok && ev2 != nil {
+				cause = asErrorInterface(d.Rebuild(ev2))
+			}
+			data.Causes = append(data.Causes, cause)
+		}
+	}
+	d.mu.RLock()
+	fn := d.ctors[data.Code]
+	d.mu.RUnlock()
+	if fn != nil {
+		if rebuilt := fn(data); !isNil(rebuilt) {
+			return rebuilt
+		}
+	}
+	return &ErrorValue{data}
+}
+
+func asErrorInterface(err error) ErrorInterface {
+	if e2, ok := err.(ErrorInterface); ok {
+		return e2
+	}
+	return Standardize(err)
+}
+
+// RegisterDecodable declares the constructor to use when decoding errors with the given code,
+// in the DefaultDecoder.  See Decoder.Register.
+//
+// Errors:
+//
+//   - serum-error-decoder-duplicate -- if a constructor is already registered for the code.
+func RegisterDecodable(code string, fn func(Data) error) {
+	DefaultDecoder.Register(code, fn)
+}
+
+// FromJSON parses a JSON serial form of an error, using the DefaultDecoder.  See Decoder.Decode.
+//
+// Errors:
+//
+//   - any error from ErrorValue.UnmarshalJSON -- if the JSON is not a valid Serum error.
+func FromJSON(b []byte) (error, error) {
+	return DefaultDecoder.Decode(b)
+}
@@ -0,0 +1,86 @@
+package serum_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/serum-errors/go-serum"
+)
+
+// quotaExceeded is a user-defined error type, which is rebuilt by the decoder.
+type quotaExceeded struct {
+	Account string
+	Cause   error
+}
+
+func (e *quotaExceeded) Code() string         { return "test-error-quota" }
+func (e *quotaExceeded) Template() string     { return "quota exceeded for {{account}}" }
+func (e *quotaExceeded) Message() string      { return serum.SynthesizeMessage(e) }
+func (e *quotaExceeded) Details() [][2]string { return [][2]string{{"account", e.Account}} }
+func (e *quotaExceeded) Unwrap() error        { return e.Cause }
+func (e *quotaExceeded) Error() string        { return serum.SynthesizeString(e) }
+
+func TestDecoder(t *testing.T) {
+	dec := serum.NewDecoder()
+	dec.Register("test-error-quota", func(d serum.Data) error {
+		e := &quotaExceeded{}
+		for _, ent := range d.Details {
+			if ent[0] == "account" {
+				e.Account = ent[1]
+			}
+		}
+		if len(d.Causes) > 0 {
+			e.Cause = d.Causes[0]
+		}
+		return e
+	})
+	dec.Register("test-error-declined", func(d serum.Data) error {
+		return nil // Falls back to ErrorValue.
+	})
+
+	original := serum.Error("test-error-outer",
+		serum.WithCause(&quotaExceeded{Account: "acme", Cause: serum.Error("test-error-declined")}),
+	)
+	decoded, err := dec.Decode([]byte(serum.ToJSONString(original)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := decoded.(*serum.ErrorValue); !ok {
+		t.Fatalf("unknown codes should decode to *ErrorValue, got %T", decoded)
+	}
+	var quota *quotaExceeded
+	if !errors.As(decoded, &quota) || quota.Account != "acme" {
+		t.Fatalf("errors.As should find the rebuilt type, got %v", quota)
+	}
+	if _, ok := quota.Cause.(*serum.ErrorValue); !ok || serum.Code(quota.Cause) != "test-error-declined" {
+		t.Fatalf("nested cause should be rebuilt too, got %#v", quota.Cause)
+	}
+	eqJson(t, original, decoded, true)
+
+	t.Run("null", func(t *testing.T) {
+		if decoded, err := serum.FromJSON([]byte(" null ")); decoded != nil || err != nil {
+			t.Fatalf("expected nothing, got %v, %v", decoded, err)
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := serum.FromJSON([]byte(`{"code":`)); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
+
+func TestDecoderZeroValue(t *testing.T) {
+	dec := &serum.Decoder{Strict: true}
+	dec.Register("test-error-zero", func(d serum.Data) error { return &quotaExceeded{Account: "zero"} })
+	err, decodeErr := dec.Decode([]byte(`{"code":"test-error-zero"}`))
+	if decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	var qe *quotaExceeded
+	if !errors.As(err, &qe) || qe.Account != "zero" {
+		t.Fatalf("expected the registered constructor to be used, got %#v", err)
+	}
+	if _, decodeErr := dec.Decode([]byte(`{"code":"test-error-zero","extra":1}`)); serum.Code(decodeErr) != "serum-error-json-unknownfield" {
+		t.Fatalf("expected strict decoding, got %v", decodeErr)
+	}
+}
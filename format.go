@@ -0,0 +1,110 @@
+package serum
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Format implements `fmt.Formatter`.
+//
+// The "%s" and "%v" verbs print the same as Error(), and "%q" prints that quoted.
+// Other verbs, widths, and flags apply to the Error() string, as they would for a string.
+//
+// The "%+v" verb prints a multi-line report, meant for debugging (and test failure output).
+// The first line is the code and message; after that, each detail is listed (in order),
+// and then each cause, indented beneath, in the same form:
+//
+//	myapp-error-jobnotfound: job 12 not found
+//	    ID: 12
+//	    caused by: myapp-error-storage: disk unavailable
+//	        disk: /dev/sda
+//
+// Values containing line breaks are quoted, so that the report stays readable.
+// The exact layout may change over time, and is not meant to be parsed.
+//
+// The "%#v" verb prints the Data as golang syntax, including any causes (recursively).
+func (e *ErrorValue) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('+') {
+		var sb strings.Builder
+		writeReport(&sb, e, 0)
+		io.WriteString(f, sb.String())
+		return
+	}
+	if verb == 'v' && f.Flag('#') {
+		var sb strings.Builder
+		writeGoSyntax(&sb, e, 0)
+		io.WriteString(f, sb.String())
+		return
+	}
+	// Everything else (including any width and flags) formats the Error() string, as it would for other errors.
+	fmt.Fprintf(f, fmt.FormatString(f, verb), e.Error())
+}
+
+// writeReport writes the "%+v" form of any error.  The first line is not indented; the rest are indented beneath it.
+func writeReport(sb *strings.Builder, err error, depth int) {
+	sb.WriteString(Code(err))
+	if msg := serialMessage(err); msg != "" {
+		sb.WriteString(": ")
+		sb.WriteString(reportValue(msg))
+	}
+	indent := strings.Repeat("    ", depth+1)
+	for _, ent := range Details(err) {
+		sb.WriteByte('\n')
+		sb.WriteString(indent)
+		sb.WriteString(ent[0])
+		sb.WriteString(": ")
+		sb.WriteString(reportValue(ent[1]))
+	}
+	if depth >= walkDepthLimit {
+		return
+	}
+	for _, cause := range liveCauses(err) {
+		sb.WriteByte('\n')
+		sb.WriteString(indent)
+		sb.WriteString("caused by: ")
+		writeReport(sb, cause, depth+1)
+	}
+}
+
+func reportValue(s string) string {
+	if strings.ContainsAny(s, "\r\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// writeGoSyntax writes the "%#v" form of an ErrorValue.
+// This is like what fmt would produce on its own, except that causes are printed in full, rather than as pointers
+// (up to the same depth limit as Walk; beyond that, they're printed as pointers after all).
+func writeGoSyntax(sb *strings.Builder, e *ErrorValue, depth int) {
+	fmt.Fprintf(sb, "&serum.ErrorValue{Data:serum.Data{Code:%#v, Message:%#v, Details:%#v, Causes:", e.Data.Code, e.Data.Message, e.Data.Details)
+	if e.Data.Causes == nil {
+		sb.WriteString("[]serum.ErrorInterface(nil)")
+	} else {
+		sb.WriteString("[]serum.ErrorInterface{")
+		for i, cause := range e.Data.Causes {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			if ev, ok := cause.(*ErrorValue); ok && ev != nil {
+				if depth >= walkDepthLimit {
+					fmt.Fprintf(sb, "(*serum.ErrorValue)(%p)", ev)
+				} else {
+					writeGoSyntax(sb, ev, depth+1)
+				}
+			} else {
+				fmt.Fprintf(sb, "%#v", cause)
+			}
+		}
+		sb.WriteString("}")
+	}
+	fmt.Fprintf(sb, ", Extensions:%#v, Original:", e.Data.Extensions)
+	if e.Data.Original == nil {
+		sb.WriteString("error(nil)")
+	} else {
+		fmt.Fprintf(sb, "%#v", e.Data.Original)
+	}
+	sb.WriteString("}}")
+}
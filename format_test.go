@@ -0,0 +1,59 @@
+package serum_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/serum-errors/go-serum"
+)
+
+func TestFormat(t *testing.T) {
+	err := serum.Error("test-error-outer",
+		serum.WithMessageTemplate("job {{ID}} failed"),
+		serum.WithDetail("ID", "12"),
+		serum.WithDetail("log", "line 1\nline 2"),
+		serum.WithCause(serum.Error("test-error-inner", serum.WithDetail("k", "v"), serum.WithCause(serum.Error("test-error-innermost")))),
+		serum.WithCause(errors.New("plain")),
+	)
+	for _, tr := range []struct {
+		format string
+		expect string
+	}{
+		{"%v", err.Error()},
+		{"%s", err.Error()},
+		{"%q", fmt.Sprintf("%q", err.Error())},
+		{"%x", fmt.Sprintf("%x", err.Error())},
+		{"%X", fmt.Sprintf("%X", err.Error())},
+		{"%-80s|", fmt.Sprintf("%-80s|", err.Error())},
+		{"%.16v", err.Error()[:16]},
+		{"%+v", "test-error-outer: job 12 failed\n" +
+			"    ID: 12\n" +
+			"    log: \"line 1\\nline 2\"\n" +
+			"    caused by: test-error-inner\n" +
+			"        k: v\n" +
+			"        caused by: test-error-innermost\n" +
+			"    caused by: bestguess-golang-errors-errorString: plain"},
+		{"%#v", `&serum.ErrorValue{Data:serum.Data{Code:"test-error-outer", Message:"job 12 failed", Details:[][2]string{[2]string{"ID", "12"}, [2]string{"log", "line 1\nline 2"}}, Causes:[]serum.ErrorInterface{` +
+			`&serum.ErrorValue{Data:serum.Data{Code:"test-error-inner", Message:"", Details:[][2]string{[2]string{"k", "v"}}, Causes:[]serum.ErrorInterface{` +
+			`&serum.ErrorValue{Data:serum.Data{Code:"test-error-innermost", Message:"", Details:[][2]string(nil), Causes:[]serum.ErrorInterface(nil), Extensions:[][2]string(nil), Original:error(nil)}}` +
+			`}, Extensions:[][2]string(nil), Original:error(nil)}}, ` +
+			`&serum.ErrorValue{Data:serum.Data{Code:"bestguess-golang-errors-errorString", Message:"plain", Details:[][2]string(nil), Causes:[]serum.ErrorInterface(nil), Extensions:[][2]string(nil), Original:&errors.errorString{s:"plain"}}}` +
+			`}, Extensions:[][2]string(nil), Original:error(nil)}}`},
+	} {
+		if result := fmt.Sprintf(tr.format, err); result != tr.expect {
+			t.Errorf("for %s: mismatch:\n\tresult: %s\n\texpect: %s", tr.format, result, tr.expect)
+		}
+	}
+
+	t.Run("deep chains are cut off", func(t *testing.T) {
+		deep := serum.Error("test-error-deep")
+		for i := 0; i < 2000; i++ {
+			deep = serum.Error("test-error-deep", serum.WithCause(deep))
+		}
+		if result := fmt.Sprintf("%#v", deep); strings.Count(result, "test-error-deep") != 1001 || !strings.Contains(result, "(*serum.ErrorValue)(0x") {
+			t.Errorf("expected the %%#v form to stop after the depth limit")
+		}
+	})
+}
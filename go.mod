@@ -1,3 +1,3 @@
 module github.com/serum-errors/go-serum
 
-go 1.17
+go 1.20
@@ -3,9 +3,12 @@ package serum
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"reflect"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
 )
 
 // ToJSON is a helper function to turn any error into JSON.
@@ -20,42 +23,238 @@ import (
 //
 // In fudge mode: the golang type will appear as part of the serum code;
 // the `Error() string` will be used as a message;
-// `errors.Unwrap` will be used to find a cause; etc.
+// `serum.Causes` will be used to find causes; etc.
+//
+// A single cause is serialized in the "cause" field.
+// If there are several causes, they are serialized as a list in the "causes" field instead.
+//
+// The output is compact (it contains no insignificant whitespace).
+// The returned error is always nil; it's present so this function matches the signature of MarshalJSON.
+// See AppendJSON and WriteJSON for more efficient ways to serialize errors.
 func ToJSON(err error) ([]byte, error) {
-	// Error handling throughout this function would appear lax; it is not.
-	// Where we are encoding to a buffer, and know we are handling only strings, errors from encode are not really possible, and so the branch to check is omitted.
-	var buf bytes.Buffer
-	encoder := json.NewEncoder(&buf)
-	buf.WriteString(`{"code":`)
-	encoder.Encode(Code(err))
-	if _, ok := err.(ErrorInterface); ok {
-		if e2, ok := err.(ErrorInterfaceWithMessage); ok {
-			msg := e2.Message()
-			if msg != "" {
-				buf.WriteString(`, "message":`)
-				encoder.Encode(msg)
+	return AppendJSON(nil, err), nil
+}
+
+// AppendJSON is like ToJSON, but appends the JSON to the given byte slice, and returns the extended slice.
+//
+// This is the most efficient way to serialize errors:
+// if the given slice has enough capacity, and the error is an *ErrorValue (including its causes),
+// serializing does not allocate at all.
+// The output is compact (it contains no insignificant whitespace).
+//
+// If the error is nil, "null" is appended.
+func AppendJSON(dst []byte, err error) []byte {
+	return appendJSON(dst, err, nil, 0)
+}
+
+// appendJSON does the work of AppendJSON and ToJSONWith.
+// The opts may be nil, which means defaults (and is the fast path).
+func appendJSON(dst []byte, err error, opts *JSONOptions, depth int) []byte {
+	if err == nil {
+		return append(dst, "null"...)
+	}
+	dst = append(dst, `{"code":`...)
+	dst = opts.appendString(dst, Code(err), 0)
+	if msg := serialMessage(err); msg != "" {
+		dst = append(dst, `,"message":`...)
+		dst = opts.appendString(dst, msg, opts.maxMessageLen())
+	}
+	if details := Details(err); details != nil {
+		dst = append(dst, `,"details":`...)
+		if opts == nil {
+			dst = pairs(details).appendJSON(dst)
+		} else {
+			dst = append(dst, '{')
+			for i := range details {
+				if i > 0 {
+					dst = append(dst, ',')
+				}
+				dst = opts.appendString(dst, details[i][0], 0)
+				dst = append(dst, ':')
+				dst = opts.appendString(dst, details[i][1], opts.MaxDetailLen)
 			}
+			dst = append(dst, '}')
 		}
-	} else {
-		buf.WriteString(`, "message":`)
-		encoder.Encode(err.Error())
 	}
-	if details := Details(err); details != nil {
-		buf.WriteString(`, "details":`)
-		pairs(details).marshalJSON(&buf)
+	ev, _ := err.(*ErrorValue)
+	if opts != nil && (opts.OmitCauses || (opts.MaxDepth > 0 && depth >= opts.MaxDepth)) {
+		// Skip causes.
+	} else if ev != nil {
+		// For our own type, we range over the causes directly, because the Unwrap method has to allocate.
+		n := 0
+		for _, cause := range ev.Data.Causes {
+			if !isNil(cause) {
+				n++
+			}
+		}
+		i := 0
+		for _, cause := range ev.Data.Causes {
+			if !isNil(cause) {
+				dst = appendJSONCause(dst, cause, i, n, opts, depth+1)
+				i++
+			}
+		}
+	} else {
+		causes := liveCauses(err)
+		for i, cause := range causes {
+			dst = appendJSONCause(dst, cause, i, len(causes), opts, depth+1)
+		}
 	}
-	if cause := errors.Unwrap(err); cause != nil && !isEmptyValue(reflect.ValueOf(cause)) {
-		buf.WriteString(`, "cause":`)
-		if causeJson, err := ToJSON(cause); err != nil {
-			return nil, err
-		} else {
-			buf.Write(causeJson)
+	if ev != nil {
+		for _, ext := range ev.Data.Extensions {
+			if !opts.allowExtension(ext) {
+				continue
+			}
+			dst = append(dst, ',')
+			dst = appendJSONString(dst, ext[0])
+			dst = append(dst, ':')
+			dst = append(dst, ext[1]...)
 		}
 	}
-	buf.WriteByte('}')
+	return append(dst, '}')
+}
+
+// appendJSONCause appends the i'th of n causes.
+// A single cause is serialized in the "cause" field; several are serialized as a list in the "causes" field.
+func appendJSONCause(dst []byte, cause error, i, n int, opts *JSONOptions, depth int) []byte {
+	switch {
+	case n == 1:
+		dst = append(dst, `,"cause":`...)
+	case i == 0:
+		dst = append(dst, `,"causes":[`...)
+	default:
+		dst = append(dst, ',')
+	}
+	dst = appendJSON(dst, cause, opts, depth)
+	if n > 1 && i == n-1 {
+		dst = append(dst, ']')
+	}
+	return dst
+}
+
+// JSONOptions configures ToJSONWith.
+// The zero value means the same as ToJSON: no limits, and compact output.
+type JSONOptions struct {
+	// Prefix and Indent, if either is set, cause the output to be indented, as per `encoding/json.Indent`.
+	Prefix string
+	Indent string
+
+	// MaxDepth limits how many levels of causes are serialized.
+	// For example, 1 means that only the direct causes of the error are included, but not their causes.
+	// Zero means no limit.
+	MaxDepth int
+
+	// OmitCauses causes no causes to be serialized at all.
+	OmitCauses bool
+
+	// MaxMessageLen limits the length, in bytes, of messages.
+	// Longer messages are truncated (at a character boundary), and the TruncationMarker is appended.
+	// Zero means no limit.
+	MaxMessageLen int
+
+	// MaxDetailLen limits the length, in bytes, of detail values, in the same way as MaxMessageLen.
+	// Detail keys are never truncated.
+	// It also limits the length of the raw JSON of any extension fields (see Data.Extensions);
+	// since those can't be truncated without changing their meaning, longer ones are omitted entirely.
+	// Zero means no limit.
+	MaxDetailLen int
+
+	// TruncationMarker is appended to any truncated string.
+	// If empty, "…" is used.
+	TruncationMarker string
+
+	// InvalidUTF8 is the replacement for each byte of invalid UTF-8 in any string.
+	// If empty, the Unicode replacement character (U+FFFD) is used, which is also what ToJSON does.
+	InvalidUTF8 string
+}
+
+// ToJSONWith is like ToJSON, but with options for how the JSON is produced.
+// It's useful for producing JSON for humans (with indentation),
+// and for bounding the size of the output (e.g. for logging errors that may contain very large messages).
+//
+// The result is always a valid Serum serial form, but if any limits were applied,
+// the result may be missing some information, and will not round-trip exactly.
+//
+// Errors:
+//
+//   - none in practice; the error return is present for symmetry with ToJSON.
+func ToJSONWith(err error, opts JSONOptions) ([]byte, error) {
+	bs := appendJSON(nil, err, &opts, 0)
+	if opts.Prefix == "" && opts.Indent == "" {
+		return bs, nil
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, bs, opts.Prefix, opts.Indent); err != nil {
+		return nil, err
+	}
 	return buf.Bytes(), nil
 }
 
+// allowExtension reports whether an extension field should be serialized.
+// Fields which would collide with the spec's own fields, or which aren't valid JSON, are never serialized.
+// It's fine to call this method on nil.
+func (opts *JSONOptions) allowExtension(ext [2]string) bool {
+	switch ext[0] {
+	case "code", "message", "details", "cause", "causes":
+		return false
+	}
+	if opts != nil && opts.MaxDetailLen > 0 && len(ext[1]) > opts.MaxDetailLen {
+		return false
+	}
+	return json.Valid([]byte(ext[1]))
+}
+
+func (opts *JSONOptions) maxMessageLen() int {
+	if opts == nil {
+		return 0
+	}
+	return opts.MaxMessageLen
+}
+
+// appendString appends a JSON string literal, applying truncation (if max is nonzero) and the invalid UTF-8 replacement.
+// It's fine to call this method on nil.
+func (opts *JSONOptions) appendString(dst []byte, s string, max int) []byte {
+	if opts == nil {
+		return appendJSONString(dst, s)
+	}
+	invalid := "\ufffd"
+	if opts.InvalidUTF8 != "" {
+		invalid = opts.InvalidUTF8
+	}
+	dst = append(dst, '"')
+	if max > 0 && len(s) > max {
+		cut := max
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		marker := opts.TruncationMarker
+		if marker == "" {
+			marker = "…"
+		}
+		dst = appendJSONStringBody(dst, s[:cut], invalid)
+		dst = appendJSONStringBody(dst, marker, "\ufffd")
+	} else {
+		dst = appendJSONStringBody(dst, s, invalid)
+	}
+	return append(dst, '"')
+}
+
+var jsonBufPool = sync.Pool{New: func() interface{} { return new([]byte) }}
+
+// WriteJSON is like ToJSON, but writes the JSON to the given writer.
+// It uses pooled buffers, so it does not need to allocate a new buffer for each call.
+//
+// Errors:
+//
+//   - any error from the writer.
+func WriteJSON(w io.Writer, err error) error {
+	buf := jsonBufPool.Get().(*[]byte)
+	*buf = AppendJSON((*buf)[:0], err)
+	_, werr := w.Write(*buf)
+	jsonBufPool.Put(buf)
+	return werr
+}
+
 // ToJSONString is similar to ToJSON, but returns exactly one value,
 // which makes it easy to use in chained calls, or to hand to a Printf parameter.
 //
@@ -72,102 +271,345 @@ func ToJSONString(err error) string {
 
 // ---
 
+// UnmarshalJSON parses the Serum serial form of an error, as produced by ToJSON.
+//
+// This is the lenient mode of parsing:
+// a missing code is tolerated, duplicate detail keys are kept,
+// and any unrecognized fields are preserved in the Extensions field,
+// so that they're emitted again, unchanged, if the value is serialized again.
+// (This is useful for proxies, which may handle errors from newer systems than themselves.)
+// Causes are parsed in the same mode.
+// See UnmarshalJSONStrict for a strict mode.
+//
+// Errors:
+//
+//   - serum-error-json-syntax -- if the input is not valid JSON.
+//   - serum-error-json-invalid -- if a field has the wrong type of value.
 func (e *ErrorValue) UnmarshalJSON(b []byte) error {
-	var target struct {
-		// Yes, this is almost serum.Data itself, but:
-		// - this uses the unexported 'pairs' type,
-		// - this needs a concrete type for the cause, or things don't fly right,
-		// - and not having the json tags on the serum.Data type just seems wise, since it doesn't actually use them and there's no sense in misleading a reader.
-		Code    string      `json:"code"`
-		Message string      `json:"message,omitempty"`
-		Details pairs       `json:"details,omitempty"`
-		Cause   *ErrorValue `json:"cause,omitempty"`
-	}
-	if err := json.Unmarshal(b, &target); err != nil {
-		return err
-	}
-	e.Data.Code = target.Code
-	e.Data.Message = target.Message
-	e.Data.Details = target.Details
-	e.Data.Cause = target.Cause
-	return nil
-}
-
-func (e *ErrorValue) MarshalJSON() ([]byte, error) {
-	return ToJSON(e)
+	return e.unmarshalJSON(b, false, "")
 }
 
-// ---
-
-// MarshalJSON on the pairs type is a kludge to get ordered map behavior.
-func (a pairs) MarshalJSON() ([]byte, error) {
-	var buf bytes.Buffer
-	err := a.marshalJSON(&buf)
-	return buf.Bytes(), err
+// UnmarshalJSONStrict parses the Serum serial form of an error, rejecting anything that doesn't strictly follow the Serum spec.
+//
+// In contrast to UnmarshalJSON, this mode rejects: a missing or empty code, duplicate fields, duplicate detail keys,
+// unrecognized fields, having both the "cause" and "causes" fields, and null in place of any value.
+// Causes are parsed in the same mode.
+//
+// Errors:
+//
+//   - serum-error-json-syntax -- if the input is not valid JSON.
+//   - serum-error-json-invalid -- if a field has the wrong type of value.
+//   - serum-error-json-missingcode -- if the code is missing or empty.
+//   - serum-error-json-duplicate -- if a field or detail key appears more than once.
+//   - serum-error-json-unknownfield -- if there's a field that isn't part of the Serum spec.
+func (e *ErrorValue) UnmarshalJSONStrict(b []byte) error {
+	return e.unmarshalJSON(b, true, "")
 }
 
-// marshalJSON on the pairs type is a kludge within a kludge because the stdlib interfaces for this are ridiculous.
-func (a pairs) marshalJSON(buf *bytes.Buffer) error {
-	buf.WriteByte('{')
-	encoder := json.NewEncoder(buf)
-	for i := range a {
-		if i > 0 {
-			buf.WriteByte(',')
+// unmarshalJSON does the work of both UnmarshalJSON and UnmarshalJSONStrict.
+// The path is the location of this value within the whole document (e.g. "cause.cause."), for error reporting.
+func (e *ErrorValue) unmarshalJSON(b []byte, strict bool, path string) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	tok, err := dec.Token()
+	if err != nil {
+		return errJSONSyntax(err)
+	}
+	if tok == nil {
+		if strict {
+			return errJSONInvalid(path, "must be an object, not null")
 		}
-		if err := encoder.Encode(a[i][0]); err != nil {
-			return err
+		return nil
+	}
+	if tok != json.Delim('{') {
+		return errJSONInvalid(path, "must be an object")
+	}
+	var data Data
+	var cause *ErrorValue
+	var causes []ErrorInterface
+	seen := make(map[string]struct{}, 4)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return errJSONSyntax(err)
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return errJSONInvalid(path, "must have string keys")
+		}
+		if _, exists := seen[key]; exists && strict {
+			return errJSONDuplicate(path + key)
 		}
-		buf.WriteByte(':')
-		if err := encoder.Encode(a[i][1]); err != nil {
+		seen[key] = struct{}{}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return errJSONSyntax(err)
+		}
+		switch key {
+		case "code":
+			err = decodeJSONString(raw, &data.Code, strict, path+key)
+		case "message":
+			err = decodeJSONString(raw, &data.Message, strict, path+key)
+		case "details":
+			data.Details, err = decodeJSONDetails(raw, strict, path+key)
+		case "cause":
+			if isJSONNull(raw) && !strict {
+				continue
+			}
+			cause = &ErrorValue{}
+			err = cause.unmarshalJSON(raw, strict, path+key+".")
+		case "causes":
+			causes, err = decodeJSONCauses(raw, strict, path+key)
+		default:
+			if strict {
+				return errJSONUnknownField(path + key)
+			}
+			var buf bytes.Buffer
+			json.Compact(&buf, raw) // Can't fail; the decoder already checked it.
+			data.Extensions = append(data.Extensions, [2]string{key, buf.String()})
+		}
+		if err != nil {
 			return err
 		}
 	}
-	buf.WriteByte('}')
+	if _, err := dec.Token(); err != nil { // The closing brace.
+		return errJSONSyntax(err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errJSONSyntax(fmt.Errorf("unexpected data after the end of the error object"))
+	}
+	if strict {
+		if data.Code == "" {
+			return Error("serum-error-json-missingcode",
+				WithMessageTemplate("deserializing a serum error: {{field|q}} must have a code"),
+				WithDetail("field", strings.TrimSuffix(path, ".")),
+			)
+		}
+		if cause != nil && causes != nil {
+			return errJSONInvalid(path+"causes", "cannot be used together with \"cause\"")
+		}
+	}
+	if cause != nil {
+		data.Causes = append(data.Causes, cause)
+	}
+	data.Causes = append(data.Causes, causes...)
+	e.Data = data
 	return nil
 }
 
-func (a *pairs) UnmarshalJSON(b []byte) error {
-	dec := json.NewDecoder(bytes.NewReader(b))
+func isJSONNull(raw json.RawMessage) bool {
+	return string(raw) == "null"
+}
+
+func decodeJSONString(raw json.RawMessage, into *string, strict bool, path string) error {
+	if isJSONNull(raw) && !strict {
+		*into = ""
+		return nil
+	}
+	if len(raw) == 0 || raw[0] != '"' {
+		return errJSONInvalid(path, "must be a string")
+	}
+	if err := json.Unmarshal(raw, into); err != nil {
+		return errJSONSyntax(err)
+	}
+	return nil
+}
+
+func decodeJSONDetails(raw json.RawMessage, strict bool, path string) ([][2]string, error) {
+	if isJSONNull(raw) && !strict {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
 	if tok, err := dec.Token(); err != nil {
-		return err
-	} else if tok != '{' {
-		return fmt.Errorf("deserializing a serum error: details field must be a map")
+		return nil, errJSONSyntax(err)
+	} else if tok != json.Delim('{') {
+		return nil, errJSONInvalid(path, "must be a map")
 	}
-	for {
-		token, err := dec.Token()
+	var details [][2]string
+	for dec.More() {
+		tok, err := dec.Token()
 		if err != nil {
-			return err
+			return nil, errJSONSyntax(err)
 		}
-		if delim, ok := token.(json.Delim); ok && delim == '}' {
-			return nil
+		key, ok := tok.(string)
+		if !ok {
+			return nil, errJSONInvalid(path, "must have string keys")
 		}
-		key := token.(string)
-		token, err = dec.Token()
+		if strict {
+			for _, ent := range details {
+				if ent[0] == key {
+					return nil, errJSONDuplicate(path + "." + key)
+				}
+			}
+		}
+		tok, err = dec.Token()
 		if err != nil {
-			return err
+			return nil, errJSONSyntax(err)
 		}
-		if value, ok := token.(string); !ok {
-			return fmt.Errorf("deserializing a serum error: only strings are permitted in details map values")
-		} else {
-			*a = append(*a, [2]string{key, value})
+		value, ok := tok.(string)
+		if !ok {
+			return nil, errJSONInvalid(path+"."+key, "must be a string (only strings are permitted in details map values)")
+		}
+		details = append(details, [2]string{key, value})
+	}
+	if details == nil {
+		details = [][2]string{}
+	}
+	return details, nil
+}
+
+func decodeJSONCauses(raw json.RawMessage, strict bool, path string) ([]ErrorInterface, error) {
+	if isJSONNull(raw) && !strict {
+		return nil, nil
+	}
+	if len(raw) == 0 || raw[0] != '[' {
+		return nil, errJSONInvalid(path, "must be a list")
+	}
+	var raws []json.RawMessage
+	if err := json.Unmarshal(raw, &raws); err != nil {
+		return nil, errJSONSyntax(err)
+	}
+	causes := make([]ErrorInterface, 0, len(raws))
+	for i, raw := range raws {
+		if isJSONNull(raw) && !strict {
+			continue
+		}
+		cause := &ErrorValue{}
+		if err := cause.unmarshalJSON(raw, strict, path+"."+strconv.Itoa(i)+"."); err != nil {
+			return nil, err
+		}
+		causes = append(causes, cause)
+	}
+	return causes, nil
+}
+
+func errJSONSyntax(err error) error {
+	return Error("serum-error-json-syntax",
+		WithMessageTemplate("deserializing a serum error: malformed json: {{reason}}"),
+		WithDetail("reason", err.Error()),
+	)
+}
+
+func errJSONInvalid(field string, reason string) error {
+	field = strings.TrimSuffix(field, ".")
+	if field == "" {
+		return Error("serum-error-json-invalid",
+			WithMessageTemplate("deserializing a serum error: the error {{reason}}"),
+			WithDetail("reason", reason),
+		)
+	}
+	return Error("serum-error-json-invalid",
+		WithMessageTemplate("deserializing a serum error: field {{field|q}} {{reason}}"),
+		WithDetail("field", field),
+		WithDetail("reason", reason),
+	)
+}
+
+func errJSONDuplicate(field string) error {
+	return Error("serum-error-json-duplicate",
+		WithMessageTemplate("deserializing a serum error: {{field|q}} appears more than once"),
+		WithDetail("field", field),
+	)
+}
+
+func errJSONUnknownField(field string) error {
+	return Error("serum-error-json-unknownfield",
+		WithMessageTemplate("deserializing a serum error: {{field|q}} is not a field in the serum spec"),
+		WithDetail("field", field),
+	)
+}
+
+// MarshalJSON produces the Serum serial form of the error, as per ToJSON.
+// Any Extensions (unrecognized fields kept by UnmarshalJSON) are emitted again, unchanged.
+func (e *ErrorValue) MarshalJSON() ([]byte, error) {
+	return ToJSON(e)
+}
+
+// ---
+
+// MarshalJSON on the pairs type is a kludge to get ordered map behavior.
+func (a pairs) MarshalJSON() ([]byte, error) {
+	return a.appendJSON(nil), nil
+}
+
+// appendJSON on the pairs type is a kludge within a kludge because the stdlib interfaces for this are ridiculous.
+func (a pairs) appendJSON(dst []byte) []byte {
+	dst = append(dst, '{')
+	for i := range a {
+		if i > 0 {
+			dst = append(dst, ',')
 		}
+		dst = appendJSONString(dst, a[i][0])
+		dst = append(dst, ':')
+		dst = appendJSONString(dst, a[i][1])
 	}
+	return append(dst, '}')
 }
 
-func isEmptyValue(v reflect.Value) bool {
-	switch v.Kind() {
-	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
-		return v.Len() == 0
-	case reflect.Bool:
-		return !v.Bool()
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return v.Int() == 0
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-		return v.Uint() == 0
-	case reflect.Float32, reflect.Float64:
-		return v.Float() == 0
-	case reflect.Interface, reflect.Pointer:
-		return v.IsNil()
+// appendJSONString appends a JSON string literal.
+//
+// The escaping is the same as encoding/json does by default:
+// the usual control character escapes, HTML-sensitive characters ('<', '>', '&') escaped,
+// U+2028 and U+2029 escaped (for the benefit of JSONP), and invalid UTF-8 replaced with U+FFFD.
+// We do it ourselves because encoding/json has no API for encoding a string without allocations.
+func appendJSONString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	dst = appendJSONStringBody(dst, s, "\ufffd")
+	return append(dst, '"')
+}
+
+// appendJSONStringBody does the work of appendJSONString, but without the surrounding quotes,
+// and with a choice of what to replace invalid UTF-8 with.
+// The replacement is itself escaped.
+func appendJSONStringBody(dst []byte, s string, invalid string) []byte {
+	const hex = "0123456789abcdef"
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				dst = append(dst, '\\', b)
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			if invalid == "\ufffd" {
+				dst = append(dst, invalid...)
+			} else {
+				dst = appendJSONStringBody(dst, invalid, "\ufffd")
+			}
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hex[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
 	}
-	return false
+	return append(dst, s[start:]...)
 }
@@ -1 +1,241 @@
 package serum_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/serum-errors/go-serum"
+)
+
+func TestJSONCauses(t *testing.T) {
+	tt := []struct {
+		name   string
+		err    error
+		expect string
+	}{
+		{"no cause", serum.Error("test"),
+			`{"code":"test"}`},
+		{"one cause", serum.Error("test", serum.WithCause(serum.Error("test-a"))),
+			`{"code":"test","cause":{"code":"test-a"}}`},
+		{"several causes", serum.Error("test", serum.WithCause(serum.Error("test-a")), serum.WithCause(serum.Error("test-b"))),
+			`{"code":"test","causes":[{"code":"test-a"},{"code":"test-b"}]}`},
+	}
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			bs, err := json.Marshal(test.err)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(bs) != test.expect {
+				t.Fatalf("mismatch:\n\tresult: %s\n\texpect: %s", bs, test.expect)
+			}
+			var ev serum.ErrorValue
+			if err := json.Unmarshal(bs, &ev); err != nil {
+				t.Fatal(err)
+			}
+			bs2, err := json.Marshal(&ev)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(bs2) != test.expect {
+				t.Fatalf("round trip mismatch:\n\tresult: %s\n\texpect: %s", bs2, test.expect)
+			}
+		})
+	}
+}
+
+func TestJSONStringEscaping(t *testing.T) {
+	for _, s := range []string{
+		"plain",
+		`quote " and backslash \`,
+		"control \x00 \x01 \x1f \b \f \n \r \t",
+		"html <script>&amp;</script>",
+		"unicode ✓     \U0001F600",
+		"invalid \xff\xfe utf8 \xe2\x28\xa1",
+	} {
+		expect, err := json.Marshal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := serum.AppendJSON(nil, serum.Error("x", serum.WithMessageLiteral(s), serum.WithDetail(s, s)))
+		expectAll := `{"code":"x","message":` + string(expect) + `,"details":{` + string(expect) + `:` + string(expect) + `}}`
+		if string(result) != expectAll {
+			t.Errorf("mismatch:\n\tresult: %s\n\texpect: %s", result, expectAll)
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	err := serum.Error("test", serum.WithCause(errors.New("plain")))
+	if werr := serum.WriteJSON(&buf, err); werr != nil {
+		t.Fatal(werr)
+	}
+	if expect := serum.ToJSONString(err); buf.String() != expect {
+		t.Fatalf("mismatch:\n\tresult: %s\n\texpect: %s", buf.String(), expect)
+	}
+	if s := string(serum.AppendJSON([]byte("prefix "), nil)); s != "prefix null" {
+		t.Fatalf("unexpected result for nil: %s", s)
+	}
+}
+
+func benchmarkError() error {
+	return serum.Error("bench-error-outer",
+		serum.WithMessageTemplate("job {{ID}} failed in queue {{queue|q}}"),
+		serum.WithDetail("ID", "asdf-qwer-zxcv"),
+		serum.WithDetail("queue", "<default>"),
+		serum.WithCause(serum.Error("bench-error-inner", serum.WithMessageLiteral("disk full"))),
+	)
+}
+
+func BenchmarkAppendJSON(b *testing.B) {
+	err := benchmarkError()
+	buf := make([]byte, 0, 1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf = serum.AppendJSON(buf[:0], err)
+	}
+}
+
+func BenchmarkWriteJSON(b *testing.B) {
+	err := benchmarkError()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		serum.WriteJSON(io.Discard, err)
+	}
+}
+
+func BenchmarkToJSON(b *testing.B) {
+	err := benchmarkError()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		serum.ToJSON(err)
+	}
+}
+
+func BenchmarkJSONMarshal(b *testing.B) {
+	err := benchmarkError()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		json.Marshal(err)
+	}
+}
+
+func TestToJSONWith(t *testing.T) {
+	err := serum.Error("test",
+		serum.WithMessageLiteral("a very long message, ünïcödé"),
+		serum.WithDetail("key", "a very long value"),
+		serum.WithCause(serum.Error("test-a", serum.WithCause(serum.Error("test-a1")))),
+	)
+	tt := []struct {
+		name   string
+		opts   serum.JSONOptions
+		expect string
+	}{
+		{"defaults", serum.JSONOptions{},
+			`{"code":"test","message":"a very long message, ünïcödé","details":{"key":"a very long value"},"cause":{"code":"test-a","cause":{"code":"test-a1"}}}`},
+		{"truncation", serum.JSONOptions{MaxMessageLen: 22, MaxDetailLen: 6, OmitCauses: true},
+			`{"code":"test","message":"a very long message, …","details":{"key":"a very…"}}`},
+		{"custom marker", serum.JSONOptions{MaxMessageLen: 23, TruncationMarker: "[...]", OmitCauses: true},
+			`{"code":"test","message":"a very long message, ü[...]","details":{"key":"a very long value"}}`},
+		{"depth", serum.JSONOptions{MaxDepth: 1},
+			`{"code":"test","message":"a very long message, ünïcödé","details":{"key":"a very long value"},"cause":{"code":"test-a"}}`},
+		{"indent", serum.JSONOptions{Indent: "  ", OmitCauses: true, MaxMessageLen: 1, MaxDetailLen: 1},
+			"{\n  \"code\": \"test\",\n  \"message\": \"a…\",\n  \"details\": {\n    \"key\": \"a…\"\n  }\n}"},
+	}
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			bs, err := serum.ToJSONWith(err, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(bs) != test.expect {
+				t.Fatalf("mismatch:\n\tresult: %s\n\texpect: %s", bs, test.expect)
+			}
+		})
+	}
+	t.Run("invalid utf8", func(t *testing.T) {
+		bs, _ := serum.ToJSONWith(serum.Error("test", serum.WithMessageLiteral("bad \xff byte")), serum.JSONOptions{InvalidUTF8: "?"})
+		if expect := `{"code":"test","message":"bad ? byte"}`; string(bs) != expect {
+			t.Fatalf("mismatch:\n\tresult: %s\n\texpect: %s", bs, expect)
+		}
+	})
+}
+
+func TestUnmarshalJSONModes(t *testing.T) {
+	tt := []struct {
+		name       string
+		json       string
+		lenientErr string // Expected error code in lenient mode, or empty for success.
+		strictErr  string // Expected error code in strict mode, or empty for success.
+	}{
+		{"minimal", `{"code":"a"}`, "", ""},
+		{"complete", `{"code":"a","message":"m","details":{"k":"v"},"causes":[{"code":"b"},{"code":"c"}]}`, "", ""},
+		{"missing code", `{"message":"m"}`, "", "serum-error-json-missingcode"},
+		{"empty code", `{"code":""}`, "", "serum-error-json-missingcode"},
+		{"missing nested code", `{"code":"a","cause":{}}`, "", "serum-error-json-missingcode"},
+		{"unknown field", `{"code":"a","x-trace":{"id":1}}`, "", "serum-error-json-unknownfield"},
+		{"duplicate detail", `{"code":"a","details":{"k":"1","k":"2"}}`, "", "serum-error-json-duplicate"},
+		{"duplicate field", `{"code":"a","code":"b"}`, "", "serum-error-json-duplicate"},
+		{"cause and causes", `{"code":"a","cause":{"code":"b"},"causes":[{"code":"c"}]}`, "", "serum-error-json-invalid"},
+		{"null message", `{"code":"a","message":null}`, "", "serum-error-json-invalid"},
+		{"numeric code", `{"code":1}`, "serum-error-json-invalid", "serum-error-json-invalid"},
+		{"numeric detail", `{"code":"a","details":{"k":1}}`, "serum-error-json-invalid", "serum-error-json-invalid"},
+		{"details list", `{"code":"a","details":["k"]}`, "serum-error-json-invalid", "serum-error-json-invalid"},
+		{"not an object", `["a"]`, "serum-error-json-invalid", "serum-error-json-invalid"},
+		{"truncated", `{"code":"a"`, "serum-error-json-syntax", "serum-error-json-syntax"},
+		{"trailing data", `{"code":"a"} {}`, "serum-error-json-syntax", "serum-error-json-syntax"},
+	}
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			var ev serum.ErrorValue
+			if err := ev.UnmarshalJSON([]byte(test.json)); serum.Code(err) != test.lenientErr {
+				t.Errorf("lenient: expected error code %q, got %v", test.lenientErr, err)
+			}
+			if err := ev.UnmarshalJSONStrict([]byte(test.json)); serum.Code(err) != test.strictErr {
+				t.Errorf("strict: expected error code %q, got %v", test.strictErr, err)
+			}
+		})
+	}
+
+	t.Run("extensions are preserved", func(t *testing.T) {
+		in := `{"code":"a", "x-trace": {"id": 1,"span":"s"},"cause":{"code":"b","retryable":true},"x-later":[1, 2]}`
+		var ev serum.ErrorValue
+		if err := json.Unmarshal([]byte(in), &ev); err != nil {
+			t.Fatal(err)
+		}
+		bs, err := json.Marshal(&ev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := `{"code":"a","cause":{"code":"b","retryable":true},"x-trace":{"id":1,"span":"s"},"x-later":[1,2]}`
+		if string(bs) != expect {
+			t.Fatalf("mismatch:\n\tresult: %s\n\texpect: %s", bs, expect)
+		}
+	})
+	t.Run("bad extensions are skipped", func(t *testing.T) {
+		ev := &serum.ErrorValue{Data: serum.Data{
+			Code:       "a",
+			Extensions: [][2]string{{"code", `"b"`}, {"causes", `[]`}, {"x-broken", `{`}, {"x-ok", `1`}},
+		}}
+		expect := `{"code":"a","x-ok":1}`
+		if result := serum.ToJSONString(ev); result != expect {
+			t.Fatalf("mismatch:\n\tresult: %s\n\texpect: %s", result, expect)
+		}
+	})
+
+	t.Run("extensions are bounded by MaxDetailLen", func(t *testing.T) {
+		ev := &serum.ErrorValue{Data: serum.Data{
+			Code:       "a",
+			Extensions: [][2]string{{"x-long", `"0123456789"`}, {"x-short", `"01"`}},
+		}}
+		bs, _ := serum.ToJSONWith(ev, serum.JSONOptions{MaxDetailLen: 8})
+		expect := `{"code":"a","x-short":"01"}`
+		if string(bs) != expect {
+			t.Fatalf("mismatch:\n\tresult: %s\n\texpect: %s", bs, expect)
+		}
+	})
+}
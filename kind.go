@@ -0,0 +1,141 @@
+package serum
+
+import (
+	"strconv"
+)
+
+// Kind is a reusable definition of a kind of error:
+// its code, its message template, and the names of the details it carries.
+//
+// Define a Kind once, at package scope, and then use its New method to construct errors.
+// This avoids repeating the code and the message template at every place the error is produced,
+// and parses the template only once.
+//
+//	var ErrJobNotFound = serum.Define("myapp-error-jobnotfound", "job ID {{ID}} not found", "ID")
+//
+//	// ... later ...
+//	return ErrJobNotFound.New(jobID)
+//
+// The errors produced are ordinary *ErrorValue values,
+// exactly the same as if they had been produced by the Error constructor.
+type Kind struct {
+	code     string
+	template []parsed
+	keys     []string
+}
+
+// Define creates a new Kind of error.
+// The template follows the same syntax as WithMessageTemplate, and may be empty if no message is desired.
+// The keys are the names of the details which errors of this kind will carry,
+// and the values given to the New method later will be matched up with them in order.
+//
+// New checks the number of values it's given against the number of keys.
+// A mismatch doesn't stop an error from being produced
+// (we prefer a questionably-formed error over failing in the middle of error handling),
+// but it's made visible: the error gets a "?!arity" detail, like "expected 2 values, got 3",
+// which shows up wherever the details do (e.g. in JSON, and in "%+v" formatting).
+//
+// Define panics if the template is not well-formed (as per ParseTemplate),
+// or if the template refers to a detail key that isn't in the list of keys.
+// Define is meant to be used in package-scope var initializers,
+// so such a mistake will be found as soon as the program starts.
+//
+// Errors:
+//
+//   - serum-error-kind-invalid -- if the template is not well-formed, or refers to a key that isn't declared.
+func Define(code string, template string, keys ...string) *Kind {
+	k := &Kind{
+		code: code,
+		keys: append([]string(nil), keys...),
+	}
+	if template == "" {
+		return k
+	}
+	tmpl, err := ParseTemplate(template)
+	if err != nil {
+		panic(Error("serum-error-kind-invalid",
+			WithMessageTemplate("message template for error code {{code|q}} is not well-formed"),
+			WithDetail("code", code),
+			WithCause(err),
+		))
+	}
+	for _, key := range tmpl.Keys() {
+		if !k.hasKey(key) {
+			panic(Error("serum-error-kind-invalid",
+				WithMessageTemplate("message template for error code {{code|q}} refers to undeclared detail {{key|q}}"),
+				WithDetail("code", code),
+				WithDetail("key", key),
+			))
+		}
+	}
+	k.template = tmpl.parsed
+	return k
+}
+
+func (k *Kind) hasKey(key string) bool {
+	for _, k2 := range k.keys {
+		if k2 == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Code returns the error code of this kind of error.
+func (k *Kind) Code() string { return k.code }
+
+// New constructs an error of this kind.
+// The values are attached as details, matched up in order with the keys given to Define.
+//
+// If the number of values doesn't match the number of keys, a "?!arity" detail is attached, saying so (see Define).
+// If there are fewer values than keys, the remaining details are not attached
+// (and any reference to them in the message template will be left visible, as usual for templates).
+// If there are more values than keys, each surplus value is attached with a key saying its position, like "?!extra.2".
+func (k *Kind) New(values ...string) error {
+	return k.construct(nil, values)
+}
+
+// Wrap is like New, but also attaches a cause, as per WithCause.
+func (k *Kind) Wrap(cause error, values ...string) error {
+	return k.construct(cause, values)
+}
+
+func (k *Kind) construct(cause error, values []string) error {
+	res := &ErrorValue{Data{
+		Code: k.code,
+	}}
+	if len(values) > 0 {
+		res.Data.Details = make([][2]string, 0, len(values)+1)
+	}
+	for i, v := range values {
+		if i < len(k.keys) {
+			res.Data.Details = append(res.Data.Details, [2]string{k.keys[i], v})
+		} else {
+			res.Data.Details = append(res.Data.Details, [2]string{"?!extra." + strconv.Itoa(i), v})
+		}
+	}
+	if len(values) != len(k.keys) {
+		res.Data.Details = append(res.Data.Details, [2]string{"?!arity",
+			"expected " + strconv.Itoa(len(k.keys)) + " values, got " + strconv.Itoa(len(values)),
+		})
+	}
+	if k.template != nil {
+		res.Data.Message = interpolate(k.template, res.Data.Details)
+	}
+	if !isNil(cause) {
+		res.Data.Causes = []ErrorInterface{Standardize(cause)}
+	}
+	return res
+}
+
+// Is returns true if the error has the code of this kind.
+// Only the error itself is checked, not its causes; see Match for that.
+func (k *Kind) Is(err error) bool {
+	return err != nil && Code(err) == k.code
+}
+
+// Match returns the first error in the cause chain (including the error itself) which has the code of this kind.
+// It's the same as the Find function.
+func (k *Kind) Match(err error) (error, bool) {
+	return Find(err, k.code)
+}
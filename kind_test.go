@@ -0,0 +1,93 @@
+package serum_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/serum-errors/go-serum"
+)
+
+func ExampleDefine() {
+	var ErrJobNotFound = serum.Define("demo-error-job-not-found", "job ID {{ID}} not found", "ID")
+
+	err := ErrJobNotFound.New("12")
+	fmt.Printf("the error as a string:\n\t%v\n", err)
+	jb, jsonErr := json.MarshalIndent(err, "\t", "\t")
+	if jsonErr != nil {
+		panic(jsonErr)
+	}
+	fmt.Printf("the error as json:\n\t%s\n", jb)
+	fmt.Printf("is it a job-not-found error?\n\t%v\n", ErrJobNotFound.Is(err))
+
+	// Output:
+	// the error as a string:
+	// 	demo-error-job-not-found: job ID 12 not found
+	// the error as json:
+	// 	{
+	// 		"code": "demo-error-job-not-found",
+	// 		"message": "job ID 12 not found",
+	// 		"details": {
+	// 			"ID": "12"
+	// 		}
+	// 	}
+	// is it a job-not-found error?
+	// 	true
+}
+
+func TestKind(t *testing.T) {
+	kind := serum.Define("test-error-kind", "{{a}} and {{b}}", "a", "b")
+	t.Run("same as Error", func(t *testing.T) {
+		eqJson(t,
+			kind.New("x", "y"),
+			serum.Error("test-error-kind",
+				serum.WithMessageTemplate("{{a}} and {{b}}"),
+				serum.WithDetail("a", "x"),
+				serum.WithDetail("b", "y"),
+			),
+			true,
+		)
+	})
+	t.Run("too few values", func(t *testing.T) {
+		err := kind.New("x")
+		if s := serum.Message(err); s != "x and {{b}}" {
+			t.Fatalf("unexpected message: %s", s)
+		}
+		if s := serum.Detail(err, "?!arity"); s != "expected 2 values, got 1" {
+			t.Fatalf("arity mismatch should be visible: %v", serum.Details(err))
+		}
+	})
+	t.Run("too many values", func(t *testing.T) {
+		err := kind.New("x", "y", "z", "w")
+		expect := [][2]string{{"a", "x"}, {"b", "y"}, {"?!extra.2", "z"}, {"?!extra.3", "w"}, {"?!arity", "expected 2 values, got 4"}}
+		if result := serum.Details(err); fmt.Sprint(result) != fmt.Sprint(expect) {
+			t.Fatalf("mismatch:\n\tresult: %v\n\texpect: %v", result, expect)
+		}
+	})
+	t.Run("right number of values", func(t *testing.T) {
+		if s := serum.Detail(kind.New("x", "y"), "?!arity"); s != "" {
+			t.Fatalf("unexpected arity detail: %s", s)
+		}
+	})
+	t.Run("match", func(t *testing.T) {
+		err := serum.Error("test-error-outer", serum.WithCause(kind.Wrap(serum.Error("test-error-inner"), "x", "y")))
+		if kind.Is(err) {
+			t.Fatal("Is should only check the error itself")
+		}
+		found, ok := kind.Match(err)
+		if !ok || serum.Detail(found, "a") != "x" {
+			t.Fatalf("Match should find the error in the chain, got %v", found)
+		}
+		if serum.Code(serum.Cause(found)) != "test-error-inner" {
+			t.Fatalf("Wrap should attach the cause, got %v", found)
+		}
+	})
+	t.Run("undeclared template key panics", func(t *testing.T) {
+		defer func() {
+			if err, ok := recover().(error); !ok || serum.Code(err) != "serum-error-kind-invalid" {
+				t.Fatalf("expected a panic, got %v", err)
+			}
+		}()
+		serum.Define("test-error-kind-bad", "{{a}} and {{c}}", "a", "b")
+	})
+}
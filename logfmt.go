@@ -0,0 +1,325 @@
+package serum
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+This file contains a logfmt ("key=value" text) form of the Serum data model, for use in text logs.
+
+An error is a sequence of pairs, like this:
+
+	code=myapp-error-jobnotfound message="job 12 not found" detail.ID=12 cause.code=myapp-error-storage
+
+The keys are "code", "message", and "detail.<key>" for each detail.
+A single cause has its keys prefixed with "cause."; several causes are prefixed with "causes.0.", "causes.1.", and so on.
+(This is the same as the "cause" versus "causes" rule in the JSON form.)
+Deeper causes simply repeat the prefixes, e.g. "cause.causes.1.code".
+
+Values are quoted, Go-style (see strconv.Quote), when they are empty or contain spaces, quotes, '=', or anything unprintable.
+Keys can't be quoted in logfmt, so any such characters in detail keys (and '%' itself) are escaped as "%XX" instead.
+*/
+
+// ToLogfmt is a helper function to turn any error into a logfmt line (without a trailing newline).
+// It follows the same rules as ToJSON (including for non-Serum errors), and produces the same data, just in logfmt form.
+// If the error is nil, the result is an empty string.
+//
+// The line can be parsed back into an error with ParseLogfmt.
+func ToLogfmt(err error) string {
+	return string(AppendLogfmt(nil, err))
+}
+
+// AppendLogfmt is like ToLogfmt, but appends to the given byte slice, and returns the extended slice.
+// If dst is not empty and doesn't already end in a space, a space is added first,
+// so that the error's fields can be appended to a log line that already has other fields.
+func AppendLogfmt(dst []byte, err error) []byte {
+	if err == nil {
+		return dst
+	}
+	return appendLogfmt(dst, err, "")
+}
+
+func appendLogfmt(dst []byte, err error, prefix string) []byte {
+	dst = appendLogfmtPair(dst, prefix, "code", Code(err))
+	if msg := serialMessage(err); msg != "" {
+		dst = appendLogfmtPair(dst, prefix, "message", msg)
+	}
+	for _, ent := range Details(err) {
+		dst = appendLogfmtPair(dst, prefix, "detail."+escapeLogfmtKey(ent[0]), ent[1])
+	}
+	causes := liveCauses(err)
+	switch len(causes) {
+	case 0:
+		// Nothing to add.
+	case 1:
+		dst = appendLogfmt(dst, causes[0], prefix+"cause.")
+	default:
+		for i, cause := range causes {
+			dst = appendLogfmt(dst, cause, prefix+"causes."+strconv.Itoa(i)+".")
+		}
+	}
+	return dst
+}
+
+func appendLogfmtPair(dst []byte, prefix, key, value string) []byte {
+	if len(dst) > 0 && dst[len(dst)-1] != ' ' {
+		dst = append(dst, ' ')
+	}
+	dst = append(dst, prefix...)
+	dst = append(dst, key...)
+	dst = append(dst, '=')
+	if logfmtNeedsQuote(value) {
+		return strconv.AppendQuote(dst, value)
+	}
+	return append(dst, value...)
+}
+
+func logfmtNeedsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeLogfmtKey escapes the bytes that can't appear in a logfmt key, as "%XX".
+func escapeLogfmtKey(s string) string {
+	clean := true
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c <= ' ' || c == '=' || c == '"' || c == '%' || c == 0x7f {
+			clean = false
+			break
+		}
+	}
+	if clean {
+		return s
+	}
+	const hex = "0123456789ABCDEF"
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c == '=' || c == '"' || c == '%' || c == 0x7f {
+			sb.WriteByte('%')
+			sb.WriteByte(hex[c>>4])
+			sb.WriteByte(hex[c&0xf])
+		} else {
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+func unescapeLogfmtKey(s string) (string, bool) {
+	if !strings.Contains(s, "%") {
+		return s, true
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			sb.WriteByte(s[i])
+			continue
+		}
+		if i+2 >= len(s) {
+			return "", false
+		}
+		c, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", false
+		}
+		sb.WriteByte(byte(c))
+		i += 2
+	}
+	return sb.String(), true
+}
+
+// ---
+
+// ParseLogfmt parses a logfmt line, as produced by ToLogfmt, back into an error (including any causes).
+//
+// Keys that aren't part of the Serum form (i.e. which aren't "code", "message", "detail.*", or prefixed by "cause." or "causes.N.")
+// are ignored, so errors can be recovered from log lines which also contain other fields (such as a timestamp).
+// If the line was produced by appending to another logfmt line, as AppendLogfmt does,
+// the other line's fields must therefore not use those keys.
+//
+// Errors:
+//
+//   - serum-error-logfmt-syntax -- if the line is not valid logfmt.
+//   - serum-error-logfmt-invalid -- if the pairs don't describe a Serum error: for example, if a code is missing, a field appears more than once, or causes are numbered incorrectly.
+func ParseLogfmt(line string) (*ErrorValue, error) {
+	var root logfmtNode
+	i := 0
+	for {
+		for i < len(line) && line[i] <= ' ' {
+			i++
+		}
+		if i >= len(line) {
+			break
+		}
+		start := i
+		for i < len(line) && line[i] > ' ' && line[i] != '=' && line[i] != '"' {
+			i++
+		}
+		key := line[start:i]
+		if key == "" {
+			return nil, errLogfmtSyntax(i, "expected a key")
+		}
+		var value string
+		if i < len(line) && line[i] == '=' {
+			i++
+			if i < len(line) && line[i] == '"' {
+				end := i + 1
+				for end < len(line) && line[end] != '"' {
+					if line[end] == '\\' {
+						end++
+					}
+					end++
+				}
+				if end >= len(line) {
+					return nil, errLogfmtSyntax(i, "unterminated quoted value")
+				}
+				var err error
+				value, err = strconv.Unquote(line[i : end+1])
+				if err != nil {
+					return nil, errLogfmtSyntax(i, "invalid quoted value")
+				}
+				i = end + 1
+				if i < len(line) && line[i] > ' ' {
+					return nil, errLogfmtSyntax(i, "expected a space after a quoted value")
+				}
+			} else {
+				start := i
+				for i < len(line) && line[i] > ' ' {
+					i++
+				}
+				value = line[start:i]
+			}
+		} else if i < len(line) && line[i] == '"' {
+			return nil, errLogfmtSyntax(i, "unexpected quote in a key")
+		}
+		if err := root.set(key, value); err != nil {
+			return nil, err
+		}
+	}
+	return root.build("")
+}
+
+// logfmtNode accumulates the fields for one error (and its causes) while parsing.
+type logfmtNode struct {
+	data       Data
+	hasCode    bool
+	hasMessage bool
+	cause      *logfmtNode
+	causes     map[int]*logfmtNode
+}
+
+func (n *logfmtNode) set(key, value string) error {
+	rest := key
+	for {
+		if strings.HasPrefix(rest, "cause.") {
+			if n.causes != nil {
+				return errLogfmtInvalid(key, "mixes \"cause.\" and \"causes.\" for the same error")
+			}
+			if n.cause == nil {
+				n.cause = &logfmtNode{}
+			}
+			n, rest = n.cause, rest[len("cause."):]
+		} else if strings.HasPrefix(rest, "causes.") {
+			rest = rest[len("causes."):]
+			dot := strings.IndexByte(rest, '.')
+			if dot < 0 {
+				return errLogfmtInvalid(key, "is not a field of a cause")
+			}
+			idx, err := strconv.Atoi(rest[:dot])
+			if err != nil || idx < 0 || strconv.Itoa(idx) != rest[:dot] {
+				return errLogfmtInvalid(key, "has an invalid cause number")
+			}
+			if n.cause != nil {
+				return errLogfmtInvalid(key, "mixes \"cause.\" and \"causes.\" for the same error")
+			}
+			if n.causes == nil {
+				n.causes = make(map[int]*logfmtNode)
+			}
+			if n.causes[idx] == nil {
+				n.causes[idx] = &logfmtNode{}
+			}
+			n, rest = n.causes[idx], rest[dot+1:]
+		} else {
+			break
+		}
+	}
+	switch {
+	case rest == "code":
+		if n.hasCode {
+			return errLogfmtInvalid(key, "appears more than once")
+		}
+		n.data.Code, n.hasCode = value, true
+	case rest == "message":
+		if n.hasMessage {
+			return errLogfmtInvalid(key, "appears more than once")
+		}
+		n.data.Message, n.hasMessage = value, true
+	case strings.HasPrefix(rest, "detail."):
+		k, ok := unescapeLogfmtKey(rest[len("detail."):])
+		if !ok {
+			return errLogfmtInvalid(key, "has an invalid escape sequence")
+		}
+		n.data.Details = append(n.data.Details, [2]string{k, value})
+	default:
+		if rest != key { // Unrelated fields are fine at the top level, but not within a cause.
+			return errLogfmtInvalid(key, "is not a field of a cause")
+		}
+	}
+	return nil
+}
+
+func (n *logfmtNode) build(path string) (*ErrorValue, error) {
+	if !n.hasCode {
+		return nil, errLogfmtInvalid(path+"code", "is missing")
+	}
+	if n.cause != nil {
+		c, err := n.cause.build(path + "cause.")
+		if err != nil {
+			return nil, err
+		}
+		n.data.Causes = []ErrorInterface{c}
+	}
+	if n.causes != nil {
+		n.data.Causes = make([]ErrorInterface, len(n.causes))
+		for i := range n.data.Causes {
+			p := path + "causes." + strconv.Itoa(i) + "."
+			cn, ok := n.causes[i]
+			if !ok {
+				return nil, errLogfmtInvalid(p+"code", "is missing (causes must be numbered from 0, without gaps)")
+			}
+			c, err := cn.build(p)
+			if err != nil {
+				return nil, err
+			}
+			n.data.Causes[i] = c
+		}
+	}
+	return &ErrorValue{n.data}, nil
+}
+
+func errLogfmtSyntax(offset int, reason string) error {
+	return Error("serum-error-logfmt-syntax",
+		WithMessageTemplate("deserializing a serum error: invalid logfmt at offset {{offset}}: {{reason}}"),
+		WithDetail("offset", strconv.Itoa(offset)),
+		WithDetail("reason", reason),
+	)
+}
+
+func errLogfmtInvalid(key string, reason string) error {
+	return Error("serum-error-logfmt-invalid",
+		WithMessageTemplate("deserializing a serum error: key {{key|q}} {{reason}}"),
+		WithDetail("key", key),
+		WithDetail("reason", reason),
+	)
+}
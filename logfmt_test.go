@@ -0,0 +1,85 @@
+package serum_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/serum-errors/go-serum"
+)
+
+func TestLogfmt(t *testing.T) {
+	for name, err := range map[string]error{
+		"minimal":        serum.Error("test"),
+		"message":        serum.Error("test", serum.WithMessageLiteral("ünïcödé \"quoted\" a=b")),
+		"ordered detail": serum.Error("test", serum.WithDetail("z", "1"), serum.WithDetail("a", "")),
+		"awkward keys":   serum.Error("test", serum.WithDetail("a key=%", "\x00\xff\\")),
+		"one cause":      serum.Error("test", serum.WithCause(serum.Error("test-a", serum.WithDetail("k", "v")))),
+		"several causes": serum.Error("test", serum.WithCause(serum.Error("test-a", serum.WithCause(serum.Error("test-b")))), serum.WithCause(errors.New("plain"))),
+		"non-serum":      errors.New("plain"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ev, perr := serum.ParseLogfmt(serum.ToLogfmt(err))
+			if perr != nil {
+				t.Fatalf("parsing %s: %v", serum.ToLogfmt(err), perr)
+			}
+			if a, b := serum.ToJSONString(err), serum.ToJSONString(ev); a != b {
+				t.Fatalf("round trip mismatch:\n\toriginal: %s\n\tdecoded:  %s", a, b)
+			}
+		})
+	}
+
+	t.Run("known encoding", func(t *testing.T) {
+		err := serum.Error("myapp-error-jobnotfound",
+			serum.WithMessageTemplate("job {{ID}} not found"),
+			serum.WithDetail("ID", "12"),
+			serum.WithDetail("with space", "x y"),
+			serum.WithCause(serum.Error("myapp-error-storage")),
+			serum.WithCause(serum.Error("myapp-error-network")),
+		)
+		expect := `code=myapp-error-jobnotfound message="job 12 not found" detail.ID=12 detail.with%20space="x y" causes.0.code=myapp-error-storage causes.1.code=myapp-error-network`
+		if result := serum.ToLogfmt(err); result != expect {
+			t.Fatalf("mismatch:\n\tresult: %s\n\texpect: %s", result, expect)
+		}
+	})
+
+	t.Run("appending to a line", func(t *testing.T) {
+		line := serum.AppendLogfmt([]byte(`ts=2021-01-01T00:00:00Z level=error`), serum.Error("test", serum.WithDetail("k", "v")))
+		expect := `ts=2021-01-01T00:00:00Z level=error code=test detail.k=v`
+		if string(line) != expect {
+			t.Fatalf("mismatch:\n\tresult: %s\n\texpect: %s", line, expect)
+		}
+		ev, err := serum.ParseLogfmt(string(line))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ev.Code() != "test" || serum.Detail(ev, "k") != "v" {
+			t.Fatalf("unexpected error: %s", serum.ToJSONString(ev))
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, tr := range []struct {
+			line string
+			code string
+		}{
+			{``, "serum-error-logfmt-invalid"},
+			{`level=error`, "serum-error-logfmt-invalid"},
+			{`code=a code=b`, "serum-error-logfmt-invalid"},
+			{`code=a cause.message=x`, "serum-error-logfmt-invalid"},
+			{`code=a cause.code=b causes.0.code=c`, "serum-error-logfmt-invalid"},
+			{`code=a causes.1.code=c`, "serum-error-logfmt-invalid"},
+			{`code=a causes.01.code=c`, "serum-error-logfmt-invalid"},
+			{`code=a cause.other=x`, "serum-error-logfmt-invalid"},
+			{`code=a detail.%zz=x`, "serum-error-logfmt-invalid"},
+			{`code=a message="unterminated`, "serum-error-logfmt-syntax"},
+			{`code=a message="x"y`, "serum-error-logfmt-syntax"},
+			{`code=a =x`, "serum-error-logfmt-syntax"},
+			{`code=a message="\q"`, "serum-error-logfmt-syntax"},
+		} {
+			_, err := serum.ParseLogfmt(tr.line)
+			if serum.Code(err) != tr.code {
+				t.Errorf("for %s: expected %s, got %v", tr.line, tr.code, err)
+			}
+		}
+	})
+}
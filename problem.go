@@ -0,0 +1,217 @@
+package serum
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+/*
+This file contains conversions to and from "problem details" (RFC 7807), also known as problem+json,
+which is a format for errors in HTTP APIs that some clients require.
+
+The correspondence is:
+
+  - The "type" member is the code, appended to ProblemOptions.TypeBase.
+  - The "title" member is the Description of the code in the registry (see Register), or the code itself if it's not registered.
+  - The "detail" member is the message.
+  - The "code" member (an extension member) is the code, so that it's available without having to know the TypeBase.
+  - Each detail becomes an extension member.  Detail keys that would collide with the members above
+    (or which begin with "detail_") are prefixed with "detail_".
+  - The causes, if any, are in the "causes" extension member: an array of errors, in the Serum JSON form (see ToJSON).
+*/
+
+// ProblemContentType is the media type for problem+json.
+const ProblemContentType = "application/problem+json"
+
+// ProblemOptions configures ToProblem.
+type ProblemOptions struct {
+	// TypeBase is prepended to the code to form the "type" member, which should be a URI.
+	// For example, "https://example.com/errors/" gives types like "https://example.com/errors/myapp-error-jobnotfound".
+	// If empty, the type is just the code (which is a relative URI reference, and is permitted, but not very useful).
+	TypeBase string
+
+	// Status, if nonzero, is included as the "status" member.
+	// It should be the same as the HTTP status of the response.
+	Status int
+
+	// Instance, if set, is included as the "instance" member.
+	Instance string
+}
+
+// problemMembers are the member names which detail keys must not collide with.
+var problemMembers = map[string]struct{}{
+	"type":     {},
+	"title":    {},
+	"status":   {},
+	"detail":   {},
+	"instance": {},
+	"code":     {},
+	"causes":   {},
+}
+
+const problemDetailPrefix = "detail_"
+
+// ToProblem turns any error into problem+json.
+// It follows the same rules as ToJSON for finding the code, message, details, and causes (including for non-Serum errors).
+// If the error is nil, the result is "null".
+//
+// Errors:
+//
+//   - none in practice; the error return is present for symmetry with ToJSON.
+func ToProblem(err error, opts ProblemOptions) ([]byte, error) {
+	if err == nil {
+		return []byte("null"), nil
+	}
+	code := Code(err)
+	title := code
+	if meta, ok := Lookup(code); ok && meta.Description != "" {
+		title = meta.Description
+	}
+	dst := append([]byte(nil), `{"type":`...)
+	dst = appendJSONString(dst, opts.TypeBase+code)
+	dst = append(dst, `,"title":`...)
+	dst = appendJSONString(dst, title)
+	if opts.Status != 0 {
+		dst = append(dst, `,"status":`...)
+		dst = strconv.AppendInt(dst, int64(opts.Status), 10)
+	}
+	if msg := serialMessage(err); msg != "" {
+		dst = append(dst, `,"detail":`...)
+		dst = appendJSONString(dst, msg)
+	}
+	if opts.Instance != "" {
+		dst = append(dst, `,"instance":`...)
+		dst = appendJSONString(dst, opts.Instance)
+	}
+	dst = append(dst, `,"code":`...)
+	dst = appendJSONString(dst, code)
+	for _, ent := range Details(err) {
+		key := ent[0]
+		if _, collides := problemMembers[key]; collides || strings.HasPrefix(key, problemDetailPrefix) {
+			key = problemDetailPrefix + key
+		}
+		dst = append(dst, ',')
+		dst = appendJSONString(dst, key)
+		dst = append(dst, ':')
+		dst = appendJSONString(dst, ent[1])
+	}
+	if causes := liveCauses(err); len(causes) > 0 {
+		dst = append(dst, `,"causes":[`...)
+		for i, cause := range causes {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = AppendJSON(dst, cause)
+		}
+		dst = append(dst, ']')
+	}
+	return append(dst, '}'), nil
+}
+
+// FromProblem parses problem+json, as produced by ToProblem, back into an error.
+//
+// It also accepts problem+json from other sources.
+// If there's no "code" member, the "type" member is used as the code (or "about:blank", if that's missing too, as per RFC 7807).
+// Extension members which aren't strings become details holding their JSON text.
+// The "title", "status", and "instance" members have no equivalent in the Serum data model, and are not kept.
+//
+// Errors:
+//
+//   - serum-error-json-syntax -- if the input is not valid JSON, or there's anything after the object.
+//   - serum-error-problem-invalid -- if the input is not a JSON object, or a standard member has the wrong type of value.
+func FromProblem(b []byte) (*ErrorValue, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, errJSONSyntax(err)
+	}
+	if tok != json.Delim('{') {
+		return nil, errProblemInvalid("", "must be an object")
+	}
+	var data Data
+	var typ string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, errJSONSyntax(err)
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, errProblemInvalid("", "must have string keys")
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, errJSONSyntax(err)
+		}
+		switch key {
+		case "type", "detail", "code":
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return nil, errProblemInvalid(key, "must be a string")
+			}
+			switch key {
+			case "type":
+				typ = s
+			case "detail":
+				data.Message = s
+			case "code":
+				data.Code = s
+			}
+		case "title", "status", "instance":
+			// Not part of the Serum data model.
+		case "causes":
+			var causes []*ErrorValue
+			if err := json.Unmarshal(raw, &causes); err != nil {
+				return nil, errProblemInvalid(key, "must be an array of serum errors")
+			}
+			for _, c := range causes {
+				if c != nil {
+					data.Causes = append(data.Causes, c)
+				}
+			}
+		default:
+			value := string(raw)
+			var s string
+			if json.Unmarshal(raw, &s) == nil {
+				value = s
+			} else {
+				var buf bytes.Buffer
+				if json.Compact(&buf, raw) == nil {
+					value = buf.String()
+				}
+			}
+			data.Details = append(data.Details, [2]string{strings.TrimPrefix(key, problemDetailPrefix), value})
+		}
+	}
+	if _, err := dec.Token(); err != nil { // The closing brace.
+		return nil, errJSONSyntax(err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errJSONSyntax(fmt.Errorf("unexpected data after the end of the problem object"))
+	}
+	if data.Code == "" {
+		data.Code = typ
+		if data.Code == "" {
+			data.Code = "about:blank"
+		}
+	}
+	return &ErrorValue{data}, nil
+}
+
+func errProblemInvalid(member string, reason string) error {
+	if member == "" {
+		return Error("serum-error-problem-invalid",
+			WithMessageTemplate("deserializing a problem: the problem {{reason}}"),
+			WithDetail("reason", reason),
+		)
+	}
+	return Error("serum-error-problem-invalid",
+		WithMessageTemplate("deserializing a problem: member {{member|q}} {{reason}}"),
+		WithDetail("member", member),
+		WithDetail("reason", reason),
+	)
+}
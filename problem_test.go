@@ -0,0 +1,114 @@
+package serum_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/serum-errors/go-serum"
+)
+
+// The registry is global, so the code is registered once, rather than in the test (which may be run more than once).
+func init() {
+	serum.Register("test-error-problem-registered", serum.Meta{Description: "A registered problem"})
+}
+
+func TestToProblem(t *testing.T) {
+	for _, tr := range []struct {
+		name   string
+		err    error
+		opts   serum.ProblemOptions
+		expect string
+	}{
+		{
+			"minimal",
+			serum.Error("test-error-problem"),
+			serum.ProblemOptions{},
+			`{"type":"test-error-problem","title":"test-error-problem","code":"test-error-problem"}`,
+		},
+		{
+			"registered",
+			serum.Error("test-error-problem-registered", serum.WithMessageLiteral("msg")),
+			serum.ProblemOptions{TypeBase: "https://example.com/errors/", Status: 404, Instance: "/jobs/12"},
+			`{"type":"https://example.com/errors/test-error-problem-registered","title":"A registered problem","status":404,"detail":"msg","instance":"/jobs/12","code":"test-error-problem-registered"}`,
+		},
+		{
+			"colliding details",
+			serum.Error("test-error-problem", serum.WithDetail("ID", "12"), serum.WithDetail("type", "x"), serum.WithDetail("detail_y", "y")),
+			serum.ProblemOptions{},
+			`{"type":"test-error-problem","title":"test-error-problem","code":"test-error-problem","ID":"12","detail_type":"x","detail_detail_y":"y"}`,
+		},
+		{
+			"causes",
+			serum.Error("test-error-problem", serum.WithCause(serum.Error("test-error-cause", serum.WithDetail("k", "v")))),
+			serum.ProblemOptions{},
+			`{"type":"test-error-problem","title":"test-error-problem","code":"test-error-problem","causes":[{"code":"test-error-cause","details":{"k":"v"}}]}`,
+		},
+	} {
+		t.Run(tr.name, func(t *testing.T) {
+			bs, _ := serum.ToProblem(tr.err, tr.opts)
+			if string(bs) != tr.expect {
+				t.Fatalf("mismatch:\n\tresult: %s\n\texpect: %s", bs, tr.expect)
+			}
+			ev, err := serum.FromProblem(bs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if a, b := serum.ToJSONString(tr.err), serum.ToJSONString(ev); a != b {
+				t.Fatalf("round trip mismatch:\n\toriginal: %s\n\tdecoded:  %s", a, b)
+			}
+		})
+	}
+}
+
+func TestFromProblem(t *testing.T) {
+	t.Run("foreign", func(t *testing.T) {
+		ev, err := serum.FromProblem([]byte(`{"type":"https://example.com/probs/out-of-credit","title":"You do not have enough credit.","status":403,"balance":30,"accounts":["/account/12345", "/account/67890"]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := `{"code":"https://example.com/probs/out-of-credit","details":{"balance":"30","accounts":"[\"/account/12345\",\"/account/67890\"]"}}`
+		if result := serum.ToJSONString(ev); result != expect {
+			t.Fatalf("mismatch:\n\tresult: %s\n\texpect: %s", result, expect)
+		}
+	})
+
+	t.Run("no type", func(t *testing.T) {
+		ev, err := serum.FromProblem([]byte(`{"title":"Oops"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ev.Code() != "about:blank" {
+			t.Fatalf("unexpected code: %q", ev.Code())
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, tr := range []struct {
+			in   string
+			code string
+		}{
+			{`{`, "serum-error-json-syntax"},
+			{`{"code":"a"} trailing garbage`, "serum-error-json-syntax"},
+			{`{"code":"a"}{}`, "serum-error-json-syntax"},
+			{`[]`, "serum-error-problem-invalid"},
+			{`{"code":1}`, "serum-error-problem-invalid"},
+			{`{"causes":{}}`, "serum-error-problem-invalid"},
+		} {
+			_, err := serum.FromProblem([]byte(tr.in))
+			if serum.Code(err) != tr.code {
+				t.Errorf("for %s: expected %s, got %v", tr.in, tr.code, err)
+			}
+		}
+	})
+
+	t.Run("non-serum error", func(t *testing.T) {
+		bs, _ := serum.ToProblem(errors.New("plain"), serum.ProblemOptions{})
+		ev, err := serum.FromProblem(bs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ev.Message() != "plain" {
+			t.Fatalf("unexpected message: %q", ev.Message())
+		}
+	})
+}
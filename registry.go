@@ -0,0 +1,148 @@
+package serum
+
+import (
+	"sort"
+	"sync"
+)
+
+/*
+This file contains a registry of error codes.
+
+Registering codes is entirely optional; none of the rest of this package requires it.
+It is a place where a program can declare which codes exist, and attach some documentation to them,
+so that the set of codes can be enumerated (e.g. for generating documentation, or for debug endpoints),
+and so that codes seen at runtime can be checked against the declared set.
+
+Registration is expected to happen at init time (either in `init` functions, or in package-scope var initializers).
+It is safe to use concurrently, but registering codes long after program start is unusual.
+*/
+
+// Stability describes how settled an error code is.
+// It is part of the Meta information in the code registry.
+type Stability string
+
+const (
+	StabilityUnspecified  Stability = ""
+	StabilityExperimental Stability = "experimental"
+	StabilityStable       Stability = "stable"
+	StabilityDeprecated   Stability = "deprecated"
+)
+
+// Meta is the information that can be declared about an error code in the registry.
+// All fields are optional (the Code field is set by the Register function).
+type Meta struct {
+	Code            string    // The error code.  Set by Register; any value given to Register is ignored.
+	Description     string    // A human-readable description of what the error means.
+	DetailKeys      []string  // The detail keys that errors with this code are expected to carry.
+	MessageTemplate string    // The message template errors with this code are expected to use, if any.
+	Package         string    // The package which owns the code (typically the golang import path).
+	Stability       Stability // How settled the code is.
+}
+
+var registry = struct {
+	mu    sync.RWMutex
+	codes map[string]Meta
+}{codes: map[string]Meta{}}
+
+// Register declares an error code, along with some information about it.
+//
+// Register is meant to be called at init time.
+// It panics if the code is empty, or if the code has already been registered:
+// two declarations of the same code is a programming error,
+// and it's better to find out about it when the program starts than when the error is eventually seen.
+// For the same reason, it also panics if the MessageTemplate is not well-formed (as per ParseTemplate),
+// or if DetailKeys are declared and the MessageTemplate refers to a key that isn't among them.
+//
+// Errors:
+//
+//   - serum-error-registry-invalid -- if the code is empty, or the message template is not valid.
+//   - serum-error-registry-duplicate -- if the code was already registered.
+func Register(code string, meta Meta) {
+	if code == "" {
+		panic(Error("serum-error-registry-invalid",
+			WithMessageLiteral("cannot register an empty error code"),
+		))
+	}
+	if meta.MessageTemplate != "" {
+		checkRegisteredTemplate(code, meta)
+	}
+	meta.Code = code
+	meta.DetailKeys = append([]string(nil), meta.DetailKeys...)
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if prev, exists := registry.codes[code]; exists {
+		panic(Error("serum-error-registry-duplicate",
+			WithMessageTemplate("error code {{code|q}} is already registered (by package {{previous|q}}; now again by package {{package|q}})"),
+			WithDetail("code", code),
+			WithDetail("previous", prev.Package),
+			WithDetail("package", meta.Package),
+		))
+	}
+	registry.codes[code] = meta
+}
+
+func checkRegisteredTemplate(code string, meta Meta) {
+	tmpl, err := ParseTemplate(meta.MessageTemplate)
+	if err != nil {
+		panic(Error("serum-error-registry-invalid",
+			WithMessageTemplate("message template for error code {{code|q}} is not well-formed"),
+			WithDetail("code", code),
+			WithCause(err),
+		))
+	}
+	if len(meta.DetailKeys) == 0 {
+		return
+	}
+	for _, key := range tmpl.Keys() {
+		declared := false
+		for _, k := range meta.DetailKeys {
+			if k == key {
+				declared = true
+				break
+			}
+		}
+		if !declared {
+			panic(Error("serum-error-registry-invalid",
+				WithMessageTemplate("message template for error code {{code|q}} refers to undeclared detail {{key|q}}"),
+				WithDetail("code", code),
+				WithDetail("key", key),
+			))
+		}
+	}
+}
+
+// Lookup returns the information registered for an error code.
+// The second return value is false if the code was never registered.
+func Lookup(code string) (Meta, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	meta, ok := registry.codes[code]
+	if ok {
+		meta.DetailKeys = append([]string(nil), meta.DetailKeys...)
+	}
+	return meta, ok
+}
+
+// IsRegistered returns true if the code was ever registered.
+//
+// This is handy for checking codes observed at runtime, e.g. `serum.IsRegistered(serum.Code(err))`,
+// which will be false for any code that nobody declared -- including the "bestguess-" codes invented for non-Serum errors.
+func IsRegistered(code string) bool {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	_, ok := registry.codes[code]
+	return ok
+}
+
+// Registered returns the information for every registered error code, sorted by code.
+func Registered() []Meta {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	res := make([]Meta, 0, len(registry.codes))
+	for _, meta := range registry.codes {
+		meta.DetailKeys = append([]string(nil), meta.DetailKeys...)
+		res = append(res, meta)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Code < res[j].Code })
+	return res
+}
@@ -0,0 +1,72 @@
+package serum_test
+
+import (
+	"testing"
+
+	"github.com/serum-errors/go-serum"
+)
+
+// The registry is global, so the codes are registered once, rather than in the test (which may be run more than once).
+func init() {
+	serum.Register("test-registry-error-b", serum.Meta{
+		Description: "b happened",
+		DetailKeys:  []string{"ID"},
+		Package:     "example.net/b",
+		Stability:   serum.StabilityStable,
+	})
+	serum.Register("test-registry-error-a", serum.Meta{Package: "example.net/a"})
+}
+
+func TestRegistry(t *testing.T) {
+	meta, ok := serum.Lookup("test-registry-error-b")
+	if !ok {
+		t.Fatal("registered code should be found")
+	}
+	if meta.Code != "test-registry-error-b" || meta.Description != "b happened" || len(meta.DetailKeys) != 1 || meta.Stability != serum.StabilityStable {
+		t.Fatalf("unexpected meta: %#v", meta)
+	}
+	if _, ok := serum.Lookup("test-registry-error-missing"); ok {
+		t.Fatal("unregistered code should not be found")
+	}
+	if !serum.IsRegistered(serum.Code(serum.Error("test-registry-error-a"))) {
+		t.Fatal("registered code should be reported as registered")
+	}
+	if serum.IsRegistered("test-registry-error-missing") {
+		t.Fatal("unregistered code should not be reported as registered")
+	}
+
+	var codes []string
+	for _, meta := range serum.Registered() {
+		if meta.Code == "test-registry-error-a" || meta.Code == "test-registry-error-b" {
+			codes = append(codes, meta.Code)
+		}
+	}
+	if len(codes) != 2 || codes[0] != "test-registry-error-a" {
+		t.Fatalf("unexpected enumeration: %v", codes)
+	}
+
+	t.Run("duplicate registration panics", func(t *testing.T) {
+		defer func() {
+			r := recover()
+			err, ok := r.(error)
+			if !ok || serum.Code(err) != "serum-error-registry-duplicate" {
+				t.Fatalf("expected a duplicate registration panic, got %v", r)
+			}
+			if serum.Detail(err, "previous") != "example.net/a" {
+				t.Fatalf("panic should name the previous registrant: %v", err)
+			}
+		}()
+		serum.Register("test-registry-error-a", serum.Meta{Package: "example.net/c"})
+	})
+	t.Run("invalid template panics", func(t *testing.T) {
+		defer func() {
+			if err, ok := recover().(error); !ok || serum.Code(err) != "serum-error-registry-invalid" {
+				t.Fatalf("expected an invalid registration panic, got %v", err)
+			}
+		}()
+		serum.Register("test-registry-error-badtemplate", serum.Meta{
+			DetailKeys:      []string{"ID"},
+			MessageTemplate: "job {{ID}} in {{queue}}",
+		})
+	})
+}
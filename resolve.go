@@ -0,0 +1,136 @@
+package serum
+
+import (
+	"reflect"
+	"sync"
+)
+
+/*
+This file contains the registry of code resolvers (and details resolvers),
+which let a program decide, once and globally, what codes should be used for errors that aren't Serum errors
+(and what details should be extracted from them).
+
+Without any resolvers, the Code function invents a "bestguess-golang-" code from the golang type name,
+which is meant only to help find the source of the error.
+With resolvers, errors from third-party libraries (or the standard library) can be mapped to stable codes,
+and since every other function in this package uses Code, those codes show up everywhere:
+in Standardize, Errorf, WithCause, ToJSON, and so on.
+Details resolvers work the same way, for the Details function (and DetailsMap, and Detail).
+*/
+
+var resolvers = struct {
+	mu        sync.RWMutex
+	byType    map[reflect.Type]string
+	fns       []func(error) (string, bool)
+	detailFns []func(error) ([][2]string, bool)
+}{byType: map[reflect.Type]string{}}
+
+// RegisterCodeResolver adds a function which will be consulted by the Code function
+// for any error that isn't a Serum error (i.e. doesn't implement ErrorInterface).
+// If the function returns true, the returned string is used as the error's code.
+//
+// Resolvers are consulted in the order they were registered, after any codes registered by type
+// (see RegisterCodeForType), and before falling back to the "bestguess-golang-" code.
+// The first resolver to return true wins.
+//
+// Resolvers should be simple, fast, and must not panic: they run in the middle of error handling.
+// They should only inspect the given error itself, not its causes (Serum functions already handle causes separately).
+//
+// RegisterCodeResolver is meant to be called at init time.
+func RegisterCodeResolver(fn func(error) (string, bool)) {
+	resolvers.mu.Lock()
+	defer resolvers.mu.Unlock()
+	resolvers.fns = append(resolvers.fns, fn)
+}
+
+// RegisterCodeForType declares the code to use for any error of the same golang type as the exemplar,
+// as long as it isn't a Serum error (i.e. doesn't implement ErrorInterface).
+// The exemplar value is used only for its type, so a typed nil is fine:
+//
+//	serum.RegisterCodeForType((*somelib.TimeoutError)(nil), "somelib-error-timeout")
+//
+// Codes registered by type are consulted by the Code function before any resolver functions.
+//
+// RegisterCodeForType is meant to be called at init time.
+// It panics if a code was already registered for the same type, or if the exemplar is an untyped nil.
+//
+// Errors:
+//
+//   - serum-error-resolver-duplicate -- if a code was already registered for the type.
+//   - serum-error-resolver-invalid -- if the exemplar is nil, and so has no type.
+func RegisterCodeForType(exemplar error, code string) {
+	rt := reflect.TypeOf(exemplar)
+	if rt == nil {
+		panic(Error("serum-error-resolver-invalid",
+			WithMessageTemplate("cannot register code {{code|q}} for a nil exemplar; use a typed nil, like (*T)(nil)"),
+			WithDetail("code", code),
+		))
+	}
+	resolvers.mu.Lock()
+	defer resolvers.mu.Unlock()
+	if prev, exists := resolvers.byType[rt]; exists {
+		panic(Error("serum-error-resolver-duplicate",
+			WithMessageTemplate("a code is already registered for type {{type}} (code {{previous|q}}; now again as {{code|q}})"),
+			WithDetail("type", rt.String()),
+			WithDetail("previous", prev),
+			WithDetail("code", code),
+		))
+	}
+	resolvers.byType[rt] = code
+}
+
+// resolveCode consults the registered resolvers.
+//
+// The resolver functions are called without holding the lock,
+// since they may well call Code or Details themselves (e.g. for wrapper types),
+// and sync.RWMutex doesn't allow taking a read lock recursively.
+// (Taking the slice under the lock is enough: registering only ever appends,
+// so the entries we've got will not be changed.)
+func resolveCode(err error) (string, bool) {
+	resolvers.mu.RLock()
+	code, ok := resolvers.byType[reflect.TypeOf(err)]
+	fns := resolvers.fns
+	resolvers.mu.RUnlock()
+	if ok {
+		return code, true
+	}
+	for _, fn := range fns {
+		if code, ok := fn(err); ok {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+// RegisterDetailsResolver adds a function which will be consulted by the Details function
+// (and DetailsMap, and Detail) for any error that doesn't have a Serum-style Details method.
+// If the function returns true, the returned pairs are used as the error's details.
+//
+// This is useful together with a code resolver, for errors from third-party libraries (or the standard library)
+// which carry structured information in their fields (e.g. a file path), so that information isn't lost
+// when the error is standardized or serialized.
+//
+// Resolvers are consulted in the order they were registered, and the first to return true wins.
+// As with code resolvers, they should be simple, fast, and must not panic.
+// The returned slice should not be mutated afterwards.
+//
+// RegisterDetailsResolver is meant to be called at init time.
+func RegisterDetailsResolver(fn func(error) ([][2]string, bool)) {
+	resolvers.mu.Lock()
+	defer resolvers.mu.Unlock()
+	resolvers.detailFns = append(resolvers.detailFns, fn)
+}
+
+// resolveDetails consults the registered details resolvers.
+// As in resolveCode, the resolver functions are called without holding the lock.
+func resolveDetails(err error) ([][2]string, bool) {
+	resolvers.mu.RLock()
+	fns := resolvers.detailFns
+	resolvers.mu.RUnlock()
+	for _, fn := range fns {
+		if details, ok := fn(err); ok {
+			return details, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,121 @@
+package serum_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/serum-errors/go-serum"
+)
+
+type resolvedByType struct{}
+
+func (resolvedByType) Error() string { return "resolved by type" }
+
+type resolvedByFunc struct{ timeout bool }
+
+func (resolvedByFunc) Error() string { return "resolved by func" }
+
+// resolvedWrapper is resolved by a resolver which itself calls serum.Code on the wrapped error.
+type resolvedWrapper struct{ inner error }
+
+func (resolvedWrapper) Error() string { return "resolved wrapper" }
+
+// Resolvers are registered globally, so this is done once, rather than in the test (which may be run more than once).
+func init() {
+	serum.RegisterCodeForType(resolvedByType{}, "test-error-bytype")
+	serum.RegisterCodeResolver(func(err error) (string, bool) {
+		if e2, ok := err.(resolvedByFunc); ok && e2.timeout {
+			return "test-error-timeout", true
+		}
+		return "", false
+	})
+	serum.RegisterCodeResolver(func(err error) (string, bool) {
+		if e2, ok := err.(resolvedWrapper); ok {
+			// Registering from within a resolver would deadlock if the registry were still locked.
+			serum.RegisterDetailsResolver(func(error) ([][2]string, bool) { return nil, false })
+			return "test-error-wrapper-" + serum.Code(e2.inner), true
+		}
+		return "", false
+	})
+	serum.RegisterDetailsResolver(func(err error) ([][2]string, bool) {
+		if _, ok := err.(resolvedByType); ok {
+			return [][2]string{{"b", "2"}, {"a", "1"}}, true
+		}
+		return nil, false
+	})
+}
+
+func TestCodeResolvers(t *testing.T) {
+	if code := serum.Code(resolvedByType{}); code != "test-error-bytype" {
+		t.Errorf("unexpected code: %s", code)
+	}
+	if code := serum.Code(resolvedByFunc{timeout: true}); code != "test-error-timeout" {
+		t.Errorf("unexpected code: %s", code)
+	}
+	if code := serum.Code(resolvedByFunc{}); code != "bestguess-golang-go-serum_test-resolvedByFunc" {
+		t.Errorf("unresolved errors should still get a bestguess code, got: %s", code)
+	}
+	if code := serum.Code(serum.Error("test-error-serum")); code != "test-error-serum" {
+		t.Errorf("serum errors should be unaffected, got: %s", code)
+	}
+
+	t.Run("honored by constructors and serialization", func(t *testing.T) {
+		err := serum.Errorf("test-error-outer", "wrapping: %w", resolvedByType{})
+		if code := serum.Code(serum.Cause(err)); code != "test-error-bytype" {
+			t.Errorf("unexpected cause code: %s", code)
+		}
+		bs, jsonErr := json.Marshal(serum.Error("test-error-outer", serum.WithCause(resolvedByFunc{timeout: true})))
+		if jsonErr != nil {
+			t.Fatal(jsonErr)
+		}
+		var ev serum.ErrorValue
+		if jsonErr := json.Unmarshal(bs, &ev); jsonErr != nil {
+			t.Fatal(jsonErr)
+		}
+		if code := serum.Code(serum.Cause(&ev)); code != "test-error-timeout" {
+			t.Errorf("unexpected cause code after round trip: %s", code)
+		}
+	})
+
+	t.Run("duplicate type registration panics", func(t *testing.T) {
+		defer func() {
+			if err, ok := recover().(error); !ok || !errors.Is(err, serum.CodeSentinel("serum-error-resolver-duplicate")) {
+				t.Fatalf("expected a duplicate registration panic, got %v", err)
+			}
+		}()
+		serum.RegisterCodeForType(resolvedByType{}, "test-error-again")
+	})
+
+	t.Run("nil exemplar panics", func(t *testing.T) {
+		defer func() {
+			if err, ok := recover().(error); !ok || !errors.Is(err, serum.CodeSentinel("serum-error-resolver-invalid")) {
+				t.Fatalf("expected an invalid registration panic, got %v", err)
+			}
+		}()
+		serum.RegisterCodeForType(nil, "test-error-nil")
+	})
+
+	t.Run("resolvers may call Code", func(t *testing.T) {
+		if code := serum.Code(resolvedWrapper{resolvedByType{}}); code != "test-error-wrapper-test-error-bytype" {
+			t.Errorf("unexpected code: %s", code)
+		}
+	})
+
+	t.Run("details resolvers", func(t *testing.T) {
+		err := resolvedByType{}
+		if details := serum.Details(err); len(details) != 2 || details[0][0] != "b" {
+			t.Errorf("unexpected details: %v", details)
+		}
+		if serum.Detail(err, "a") != "1" || serum.DetailsMap(err)["b"] != "2" {
+			t.Errorf("unexpected details: %v", serum.DetailsMap(err))
+		}
+		if details := serum.Details(resolvedByFunc{}); details != nil {
+			t.Errorf("unresolved errors should have no details, got %v", details)
+		}
+		std := serum.Standardize(err)
+		if serum.Detail(std, "b") != "2" {
+			t.Errorf("standardize should use resolved details: %v", serum.Details(std))
+		}
+	})
+}
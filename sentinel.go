@@ -0,0 +1,47 @@
+package serum
+
+// CodeSentinel is an error value which is nothing but a Serum error code.
+// It is meant for use as the target of `errors.Is`, and matches any error in the cause chain which has the same code,
+// regardless of its message, details, or causes.
+//
+// Create one with a simple type conversion:
+//
+//	var ErrNotFound = serum.CodeSentinel("myapp-error-notfound")
+//
+//	// ... later ...
+//	if errors.Is(err, ErrNotFound) {
+//		// ...handle notfound...
+//	}
+//
+// This is most useful when handing Serum errors to code that is not Serum-aware,
+// but does use `errors.Is` for error comparisons.
+// (Code that is Serum-aware will usually just switch on `serum.Code` instead.)
+//
+// Matching works on any `*ErrorValue`, which means it also works on errors decoded
+// from JSON by `ErrorValue.UnmarshalJSON`.
+// Other error types match only if they implement an `Is` method that defers to Code comparison;
+// see the CodeSentinel.Is method documentation for details.
+type CodeSentinel string
+
+// Code returns the sentinel itself, as a Serum error code.
+func (s CodeSentinel) Code() string { return string(s) }
+
+// Error implements the golang error interface.  The returned string is simply the code.
+func (s CodeSentinel) Error() string { return string(s) }
+
+// Is reports whether the target error has the same code as the sentinel.
+//
+// This method is used when a CodeSentinel is the error being inspected by `errors.Is`
+// (rather than the target).
+// Error types which want to be matchable by a CodeSentinel may also use this method
+// in their own `Is` method implementation, like this:
+//
+//	func (e *MyError) Is(target error) bool {
+//		if s, ok := target.(serum.CodeSentinel); ok {
+//			return s.Is(e)
+//		}
+//		// ...
+//	}
+func (s CodeSentinel) Is(target error) bool {
+	return target != nil && string(s) == Code(target)
+}
@@ -0,0 +1,60 @@
+package serum_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/serum-errors/go-serum"
+)
+
+func TestCodeSentinel(t *testing.T) {
+	const ErrNotFound = serum.CodeSentinel("test-error-notfound")
+	t.Run("matches regardless of details", func(t *testing.T) {
+		err := serum.Error("test-error-notfound", serum.WithDetail("ID", "12"))
+		if !errors.Is(err, ErrNotFound) {
+			t.Fatal("sentinel should match by code")
+		}
+		if errors.Is(serum.Error("test-error-other"), ErrNotFound) {
+			t.Fatal("sentinel should not match other codes")
+		}
+	})
+	t.Run("matches deep in the cause chain", func(t *testing.T) {
+		err := fmt.Errorf("wrapped: %w", serum.Error("test-error-outer",
+			serum.WithCause(serum.Error("test-error-unrelated")),
+			serum.WithCause(serum.Error("test-error-notfound", serum.WithDetail("ID", "12"))),
+		))
+		if !errors.Is(err, ErrNotFound) {
+			t.Fatal("sentinel should match anywhere in the chain")
+		}
+	})
+	t.Run("matches after a json round trip", func(t *testing.T) {
+		bs, err := json.Marshal(serum.Error("test-error-outer",
+			serum.WithCause(serum.Error("test-error-notfound", serum.WithDetail("ID", "12"))),
+		))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ev serum.ErrorValue
+		if err := json.Unmarshal(bs, &ev); err != nil {
+			t.Fatal(err)
+		}
+		if !errors.Is(&ev, ErrNotFound) {
+			t.Fatal("sentinel should match decoded errors")
+		}
+	})
+	t.Run("behaves as a serum error itself", func(t *testing.T) {
+		if serum.Code(ErrNotFound) != "test-error-notfound" {
+			t.Fatalf("unexpected code: %s", serum.Code(ErrNotFound))
+		}
+		if bs, err := serum.ToJSON(ErrNotFound); err != nil {
+			t.Fatal(err)
+		} else if ev := new(serum.ErrorValue); json.Unmarshal(bs, ev) != nil || ev.Code() != "test-error-notfound" {
+			t.Fatalf("unexpected json: %s", bs)
+		}
+		if !errors.Is(ErrNotFound, serum.Error("test-error-notfound")) {
+			t.Fatal("sentinel should match errors with the same code when it is the error being inspected")
+		}
+	})
+}
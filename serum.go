@@ -36,7 +36,9 @@ type ErrorInterface interface {
 // This function takes the general "error" type and feature-detects for Serum behaviors,
 // but still has fallback behaviors for any error value.
 //
-// If the given error is _not_ recognizably Serum-styled, a code string will be invented on the fly.
+// If the given error is _not_ recognizably Serum-styled, any code resolvers that have been registered
+// (see RegisterCodeResolver and RegisterCodeForType) will be consulted.
+// If none of those produce a code, a code string will be invented on the fly.
 // This invented code string will have the prefix "bestguess-golang-" followed by a munge of the golang type name.
 // This fallback is meant to be minimally functional and help find the source of coding mistakes that lead to its creation,
 // but should not be seen in a well-formed program.
@@ -54,7 +56,11 @@ func Code(err error) string {
 		}
 		return code
 	}
-	// If it's not: we'll attempt to do something useful from the golang type name.
+	// If it's not: maybe someone told us what it should be.
+	if code, ok := resolveCode(err); ok {
+		return code
+	}
+	// If not: we'll attempt to do something useful from the golang type name.
 	// "Useful" might be a stretch, but this should at least help a developer find their questionable code quickly.
 	// We do not commit to the stability of this string.  A program of well-defined errors should not encounter this path.
 	rt := reflect.TypeOf(err)
@@ -86,6 +92,15 @@ type ErrorInterfaceWithCause interface {
 	Unwrap() error
 }
 
+// ErrorInterfaceWithCauses is implemented by errors with several causes,
+// following the same convention as `errors.Join` (and supported by `errors.Is` and `errors.As` since go1.20).
+// ErrorValue implements this interface (and not ErrorInterfaceWithCause), even when it has only one cause;
+// use the Cause or Causes functions to get causes from any error, regardless of which interface it implements.
+type ErrorInterfaceWithCauses interface {
+	ErrorInterface
+	Unwrap() []error
+}
+
 // Message returns the message field of a Serum-style error.
 //
 // This function takes the general "error" type and feature-detects for Serum behaviors,
@@ -104,6 +119,19 @@ func Message(err error) string {
 	return err.Error()
 }
 
+// serialMessage returns the message field as the serial forms (JSON, CBOR, logfmt, and the rest) record it.
+// Unlike Message, it doesn't fall back to Error() for a Serum error with no message,
+// since that would repeat the code and causes, which the serial forms record separately.
+func serialMessage(err error) string {
+	if _, ok := err.(ErrorInterface); !ok {
+		return err.Error()
+	}
+	if e2, ok := err.(ErrorInterfaceWithMessage); ok {
+		return e2.Message()
+	}
+	return ""
+}
+
 // DetailsMap returns the details of an error as a map.
 //
 // This function takes the general "error" type and feature-detects for Serum behaviors,
@@ -114,7 +142,8 @@ func Message(err error) string {
 // because it uses golang maps, this function is not order-preserving.
 //
 // If the given error is not recognizably Serum-styled,
-// this function returns an empty map.
+// any details resolvers that have been registered (see RegisterDetailsResolver) will be consulted;
+// if none of those produce details, this function returns an empty map.
 //
 // The map should not be mutated; it may be the original memory from the error value.
 func DetailsMap(err error) map[string]string {
@@ -129,6 +158,13 @@ func DetailsMap(err error) map[string]string {
 		}
 		return m
 	}
+	if l, ok := resolveDetails(err); ok {
+		m := make(map[string]string, len(l))
+		for _, ent := range l {
+			m[ent[0]] = ent[1]
+		}
+		return m
+	}
 	return map[string]string{}
 }
 
@@ -138,7 +174,8 @@ func DetailsMap(err error) map[string]string {
 // but still has fallback behaviors for any error value.
 //
 // If the given error is not recognizably Serum-styled,
-// this function returns nil.
+// any details resolvers that have been registered (see RegisterDetailsResolver) will be consulted;
+// if none of those produce details, this function returns nil.
 //
 // Note that you may also be able to use the DetailsMap to get the same content as a golang map, for convenience,
 // but be aware that access mechanism does not support order-preservation, and may often be slightly slower performance.
@@ -150,13 +187,16 @@ func Details(err error) [][2]string {
 	}
 	if e2, ok := err.(ErrorInterfaceWithDetailsMap); ok {
 		m := e2.Details()
-		l := make([][2]string, len(m))
+		l := make([][2]string, 0, len(m))
 		for k, v := range m {
 			l = append(l, [2]string{k, v})
 		}
 		sort.Sort(pairs(l))
 		return l
 	}
+	if l, ok := resolveDetails(err); ok {
+		return l
+	}
 	return nil
 }
 
@@ -178,6 +218,14 @@ func Detail(err error, whichDetail string) string {
 				return ent[1]
 			}
 		}
+		return ""
+	}
+	if l, ok := resolveDetails(err); ok {
+		for _, ent := range l {
+			if ent[0] == whichDetail {
+				return ent[1]
+			}
+		}
 	}
 	return ""
 }
@@ -188,21 +236,64 @@ func Detail(err error, whichDetail string) string {
 // but still has fallback behaviors for any error value.
 //
 // If the given error is not recognizably Serum-styled,
-// this function falls back to golang's standard `errors.Unwrap`.
+// this function falls back to golang's standard `Unwrap() error` convention.
 //
-// (This function is really only provided for completeness and consistency of naming;
-// it's functionally identical to golang's standard `errors.Unwrap`.)
+// If the error has several causes (per golang's `Unwrap() []error` convention,
+// as produced by `errors.Join`, or by `fmt.Errorf` with several %w verbs),
+// the first one is returned.
+// Use the Causes function to see all of them.
+// (This is a difference from golang's standard `errors.Unwrap`, which returns nil in that situation.)
 func Cause(err error) error {
-	// This is the same as `errors.Unwrap`.
+	// This is almost the same as `errors.Unwrap`.
 	// The code is replicated because a little copying is better than a little dependency.
 	// We don't have a `serum.ErrorValue.Cause` method and just stick to `Unwrap` naming because there's little purpose in being special here.
-	u, ok := err.(interface {
-		Unwrap() error
-	})
-	if !ok {
-		return nil
+	switch e2 := err.(type) {
+	case interface{ Unwrap() error }:
+		return e2.Unwrap()
+	case interface{ Unwrap() []error }:
+		for _, cause := range e2.Unwrap() {
+			if cause != nil {
+				return cause
+			}
+		}
+	}
+	return nil
+}
+
+// Causes returns all the causes of any Serum-style error.
+//
+// This function takes the general "error" type and feature-detects for Serum behaviors,
+// but still has fallback behaviors for any error value.
+//
+// Both of golang's conventions for unwrapping are supported:
+// errors with an `Unwrap() []error` method will have all of those values returned,
+// and errors with an `Unwrap() error` method will have that single value returned in a slice.
+// If the error has no cause, nil is returned.
+//
+// The result should not be mutated; it may be the original memory from the error value.
+func Causes(err error) []error {
+	switch e2 := err.(type) {
+	case interface{ Unwrap() []error }:
+		return e2.Unwrap()
+	case interface{ Unwrap() error }:
+		if cause := e2.Unwrap(); cause != nil {
+			return []error{cause}
+		}
+	}
+	return nil
+}
+
+// isNil returns true if the error is nil, or is a typed nil (e.g. a nil pointer in a non-nil interface).
+func isNil(err error) bool {
+	if err == nil {
+		return true
 	}
-	return u.Unwrap()
+	rv := reflect.ValueOf(err)
+	switch rv.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Pointer, reflect.Slice:
+		return rv.IsNil()
+	}
+	return false
 }
 
 // ---
@@ -213,12 +304,14 @@ func Cause(err error) error {
 // SynthesizeString will detect properties of a Serum error, and synthesize a string using them.
 // The string will contain the code, the message, and the string of the cause if present,
 // in roughly the form "{code}[: {message}][: caused by: {cause}]".
+// If there are several causes, all of them are listed,
+// in the form "{code}[: {message}]: caused by: [{cause1}; {cause2}]".
 // Entries from a details map will not be present (unless the message includes them), as per the Serum standard's recommendation.
 //
 // You can use this function to implement the `Error() string` method of a Serum error type conveniently.
 //
 // The resultant string is hoped to be human-readable.
-// It is not expected to be mechanically parsible.
+// It is not expected to be mechanically parsible.  (If you need a text form that is, see ToLogfmt.)
 // The form is primarily meant to match Golang community norms; it is not a Serum convention.
 //
 // The exact behavior of this function may change over time.
@@ -234,23 +327,43 @@ func SynthesizeString(err ErrorInterface) string {
 			sb.WriteString(msg)
 		}
 	}
-	if e2, ok := err.(ErrorInterfaceWithCause); ok {
-		cause := e2.Unwrap()
-		// We'll doublecheck for typed nil here, because if it is present, the outcome is simply too extremely silly.
-		if cause != nil && !reflect.ValueOf(cause).IsNil() {
-			sb.WriteString(": caused by: ")
+	// We'll doublecheck for typed nil here, because if it is present, the outcome is simply too extremely silly.
+	causes := liveCauses(err)
+	switch len(causes) {
+	case 0:
+		// Nothing to add.
+	case 1:
+		sb.WriteString(": caused by: ")
+		sb.WriteString(causes[0].Error())
+	default:
+		sb.WriteString(": caused by: [")
+		for i, cause := range causes {
+			if i > 0 {
+				sb.WriteString("; ")
+			}
 			sb.WriteString(cause.Error())
 		}
+		sb.WriteString("]")
 	}
 	return sb.String()
 }
 
-/*
-Not actually sure the following is valuable enough to take on a templating package dependency.
+// liveCauses returns the result of Causes, minus any nil or typed-nil values.
+func liveCauses(err error) []error {
+	causes := Causes(err)
+	live := causes[:0:0]
+	for _, cause := range causes {
+		if !isNil(cause) {
+			live = append(live, cause)
+		}
+	}
+	return live
+}
 
-// ErrorWithMessageTemplateInterface describes an error that has a message template.
+// ErrorInterfaceWithMessageTemplate describes an error that has a message template.
 // These aren't a Serum Convention standard; it's a convenience feature.
-// The template can refer to keys in the details map.
+// The template can refer to keys in the details map,
+// using the same syntax as the WithMessageTemplate constructor option.
 // Having a template attached to a type via a constant method is a way
 // to avoid having to write a custom constructor function.
 type ErrorInterfaceWithMessageTemplate interface {
@@ -260,14 +373,31 @@ type ErrorInterfaceWithMessageTemplate interface {
 
 // SynthesizeMessage produces a message string from an error.
 // (Note: this is not the entire string that describes an error; see SynthesizeString.)
-// If the error has a message template (per ErrorInterfaceWithMessageTemplate), the template will be evaluated;
+// If the error has a message template (per ErrorInterfaceWithMessageTemplate), the template will be evaluated,
+// using the error's details (as per the Details function);
 // if there is no template, a "k1=v1, k2=v2" string will be produced as a fallback.
 //
 // If there's already a message (per ErrorInterfaceWithMessage), this function disregards it.
 // This is because this function is meant primarily to help implement the Message function;
 // so, to call Message would be prone to result in endless loops in practice.
+//
+// A typical use looks like this:
+//
+//	func (e *MyError) Template() string { return "job ID {{ID}} not found" }
+//	func (e *MyError) Message() string  { return serum.SynthesizeMessage(e) }
 func SynthesizeMessage(err ErrorInterface) string {
-	panic("not yet implemented")
+	details := Details(err)
+	if e2, ok := err.(ErrorInterfaceWithMessageTemplate); ok {
+		return interpolate(parse(e2.Template()), details)
+	}
+	var sb strings.Builder
+	for i, ent := range details {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(ent[0])
+		sb.WriteString("=")
+		sb.WriteString(ent[1])
+	}
+	return sb.String()
 }
-
-*/
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 	"testing"
 
 	"github.com/serum-errors/go-serum"
@@ -146,3 +147,116 @@ func TestErrorsIs(t *testing.T) {
 		})
 	})
 }
+
+// joinedErr is a minimal stand-in for the result of `errors.Join`.
+type joinedErr []error
+
+func (e joinedErr) Error() string   { return "joined" }
+func (e joinedErr) Unwrap() []error { return e }
+
+func TestCauses(t *testing.T) {
+	a := serum.Error("test-a")
+	b := errors.New("non-serum")
+	t.Run("single cause", func(t *testing.T) {
+		err := serum.Error("test", serum.WithCause(a))
+		if causes := serum.Causes(err); len(causes) != 1 || causes[0] != a {
+			t.Fatalf("unexpected causes: %v", causes)
+		}
+		if serum.Cause(err) != a {
+			t.Fatalf("unexpected cause: %v", serum.Cause(err))
+		}
+		if s := err.Error(); s != "test: caused by: test-a" {
+			t.Fatalf("unexpected string: %s", s)
+		}
+	})
+	t.Run("several causes", func(t *testing.T) {
+		err := serum.Error("test", serum.WithCause(a), serum.WithCause(b))
+		causes := serum.Causes(err)
+		if len(causes) != 2 || causes[0] != a || serum.Code(causes[1]) != "bestguess-golang-errors-errorString" {
+			t.Fatalf("unexpected causes: %v", causes)
+		}
+		if serum.Cause(err) != a {
+			t.Fatalf("Cause should return the first cause, got %v", serum.Cause(err))
+		}
+		if !errors.Is(err, b) {
+			t.Fatal("errors.Is should find the second cause")
+		}
+		if s := err.Error(); s != "test: caused by: [test-a; bestguess-golang-errors-errorString: non-serum]" {
+			t.Fatalf("unexpected string: %s", s)
+		}
+	})
+	t.Run("standardizing a multi-cause error", func(t *testing.T) {
+		std := serum.Standardize(joinedErr{a, nil, b})
+		if causes := serum.Causes(std); len(causes) != 2 {
+			t.Fatalf("unexpected causes: %v", causes)
+		}
+		if !errors.Is(std, a) || !errors.Is(std, b) {
+			t.Fatal("errors.Is should find both causes")
+		}
+	})
+	t.Run("no causes", func(t *testing.T) {
+		err := serum.Error("test")
+		if causes := serum.Causes(err); causes != nil {
+			t.Fatalf("unexpected causes: %v", causes)
+		}
+		if serum.Cause(err) != nil {
+			t.Fatalf("unexpected cause: %v", serum.Cause(err))
+		}
+	})
+}
+
+// jobNotFound is a user-defined error type which uses a message template.
+type jobNotFound struct {
+	ID string
+}
+
+func (e *jobNotFound) Code() string         { return "test-error-jobnotfound" }
+func (e *jobNotFound) Template() string     { return "job ID {{ID|q}} not found" }
+func (e *jobNotFound) Message() string      { return serum.SynthesizeMessage(e) }
+func (e *jobNotFound) Details() [][2]string { return [][2]string{{"ID", e.ID}} }
+func (e *jobNotFound) Error() string        { return serum.SynthesizeString(e) }
+
+// untemplated is a user-defined error type which has details but no message template.
+type untemplated struct{}
+
+func (e untemplated) Code() string               { return "test-error-untemplated" }
+func (e untemplated) Details() map[string]string { return map[string]string{"b": "2", "a": "1"} }
+func (e untemplated) Error() string              { return serum.SynthesizeString(e) }
+
+func TestSynthesizeMessage(t *testing.T) {
+	if s := (&jobNotFound{"12"}).Error(); s != `test-error-jobnotfound: job ID "12" not found` {
+		t.Fatalf("unexpected string: %s", s)
+	}
+	if s := serum.SynthesizeMessage(untemplated{}); s != "a=1, b=2" {
+		t.Fatalf("unexpected fallback message: %s", s)
+	}
+	if s := serum.SynthesizeMessage(serum.CodeSentinel("test-error-nodetails")); s != "" {
+		t.Fatalf("unexpected fallback message: %s", s)
+	}
+}
+
+func TestOriginalPreserved(t *testing.T) {
+	original := &os.PathError{Op: "open", Path: "/nope", Err: os.ErrNotExist}
+	for name, err := range map[string]error{
+		"WithCause": serum.Error("test", serum.WithCause(original)),
+		"Errorf":    serum.Errorf("test", "wrapping: %w", original),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var pathErr *os.PathError
+			if !errors.As(err, &pathErr) || pathErr != original {
+				t.Fatalf("errors.As should find the original error, got %v", pathErr)
+			}
+			if !errors.Is(err, os.ErrNotExist) {
+				t.Fatal("errors.Is should see through the original error")
+			}
+			if _, ok := serum.Cause(err).(*serum.ErrorValue); !ok {
+				t.Fatalf("the cause should still be standardized, got %T", serum.Cause(err))
+			}
+			var ev serum.ErrorValue
+			if jsonErr := json.Unmarshal([]byte(serum.ToJSONString(err)), &ev); jsonErr != nil {
+				t.Fatal(jsonErr)
+			}
+			eqJson(t, err, &ev, true)
+		})
+	}
+}
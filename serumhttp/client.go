@@ -0,0 +1,94 @@
+package serumhttp
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/serum-errors/go-serum"
+)
+
+// maxErrorBody bounds how much of an error response's body CheckResponse will read.
+const maxErrorBody = 1 << 20
+
+// maxBodyExcerpt bounds the body excerpt kept in serumhttp-error-response errors.
+const maxBodyExcerpt = 512
+
+// CheckResponse returns an error if the response's status isn't 2xx, and nil otherwise.
+// It's meant to be used on the client side, with servers that send errors as WriteError does,
+// though it also produces reasonable errors for servers that don't.
+//
+// If the response body is a Serum error (in JSON), it's decoded with serum.ErrorValue.UnmarshalJSON,
+// and becomes the cause of the returned error.
+// Otherwise, the start of the body is kept in the "body" detail.
+// Either way, the returned error has "method", "url", and "status" details describing the request and response.
+//
+// If an error is returned, the response body has been read and closed.
+// If nil is returned, the response body is untouched.
+//
+// Errors:
+//
+//   - serumhttp-error-remote -- if the response is a Serum error.  The cause is the remote error.
+//   - serumhttp-error-response -- if the response is an error, but not a Serum error.
+func CheckResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+	var method, url string
+	if resp.Request != nil {
+		method = resp.Request.Method
+		if resp.Request.URL != nil {
+			url = resp.Request.URL.Redacted()
+		}
+	}
+	status := strconv.Itoa(resp.StatusCode)
+
+	body, readErr := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	resp.Body.Close()
+	if readErr == nil && isJSON(resp.Header.Get("Content-Type")) {
+		var remote serum.ErrorValue
+		if err := remote.UnmarshalJSON(body); err == nil && remote.Code() != "" {
+			return serum.Error("serumhttp-error-remote",
+				serum.WithMessageTemplate("{{method}} {{url}}: status {{status}}"),
+				serum.WithDetail("method", method),
+				serum.WithDetail("url", url),
+				serum.WithDetail("status", status),
+				serum.WithCause(&remote),
+			)
+		}
+	}
+	opts := []serum.WithConstruction{
+		serum.WithMessageTemplate("{{method}} {{url}}: status {{status}}, with a response that is not a serum error"),
+		serum.WithDetail("method", method),
+		serum.WithDetail("url", url),
+		serum.WithDetail("status", status),
+		serum.WithDetail("body", excerpt(body)),
+	}
+	if readErr != nil {
+		opts = append(opts, serum.WithCause(readErr))
+	}
+	return serum.Error("serumhttp-error-response", opts...)
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// excerpt returns the start of the body, cut at a character boundary if it's too long.
+func excerpt(body []byte) string {
+	if len(body) <= maxBodyExcerpt {
+		return string(body)
+	}
+	cut := maxBodyExcerpt
+	for cut > maxBodyExcerpt-utf8.UTFMax && !utf8.RuneStart(body[cut]) {
+		cut--
+	}
+	return string(body[:cut]) + "…"
+}
@@ -0,0 +1,69 @@
+package serumhttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/serum-errors/go-serum"
+	"github.com/serum-errors/go-serum/serumhttp"
+)
+
+func TestCheckResponse(t *testing.T) {
+	rr := serumhttp.NewResponder()
+	rr.MapCode("test-error-notfound", http.StatusNotFound)
+	mux := http.NewServeMux()
+	mux.Handle("/serum", rr.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		return serum.Error("test-error-notfound", serum.WithDetail("ID", "12"))
+	}))
+	mux.HandleFunc("/plain", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, strings.Repeat("x", 1000), http.StatusBadGateway)
+	})
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("fine"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	get := func(path string) error {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		return serumhttp.CheckResponse(resp)
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		if err := get("/ok"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("serum", func(t *testing.T) {
+		err := get("/serum")
+		if serum.Code(err) != "serumhttp-error-remote" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for k, v := range map[string]string{"method": "GET", "url": server.URL + "/serum", "status": "404"} {
+			if serum.Detail(err, k) != v {
+				t.Errorf("detail %s: mismatch:\n\tresult: %s\n\texpect: %s", k, serum.Detail(err, k), v)
+			}
+		}
+		remote := serum.Cause(err)
+		if serum.Code(remote) != "test-error-notfound" || serum.Detail(remote, "ID") != "12" {
+			t.Fatalf("unexpected cause: %v", remote)
+		}
+	})
+
+	t.Run("not serum", func(t *testing.T) {
+		err := get("/plain")
+		if serum.Code(err) != "serumhttp-error-response" || serum.Detail(err, "status") != "502" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if body := serum.Detail(err, "body"); body != strings.Repeat("x", 512)+"…" {
+			t.Fatalf("unexpected body excerpt: %q", body)
+		}
+	})
+}
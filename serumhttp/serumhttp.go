@@ -0,0 +1,264 @@
+/*
+The serumhttp package connects Serum errors to golang's `net/http` servers and clients.
+
+WriteError sends an error as an HTTP response: the body is the Serum JSON form (see serum.ToJSON),
+the status is chosen by the error's code, and the code is also sent in the "Serum-Error-Code" header
+(so that clients, proxies, and logs can see it without parsing the body).
+
+The mapping from codes to statuses is configured on a Responder, either with exact codes, or with code prefixes:
+
+	serumhttp.MapCode("myapp-error-jobnotfound", http.StatusNotFound)
+	serumhttp.MapPrefix("myapp-error-auth-", http.StatusForbidden)
+
+Codes that aren't mapped get status 500 (or the Responder's DefaultStatus, if set).
+
+Handlers can simply return errors, by using HandlerFunc:
+
+	http.Handle("/jobs/", serumhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		job, err := findJob(r.URL.Path)
+		if err != nil {
+			return err
+		}
+		// ...
+	}))
+
+And Recover turns panics into error responses, rather than dropped connections.
+
+On the client side, CheckResponse turns error responses back into errors, keeping the remote Serum error as the cause.
+
+The package-scope functions all use DefaultResponder.
+Create a separate Responder with NewResponder if different configuration is needed for different handlers.
+
+Beware that the response includes the whole error, including its message, details, and causes.
+If errors may contain information that clients shouldn't see, use the Responder's JSON options to limit what's sent
+(e.g. OmitCauses), or convert errors to suitable public ones before returning them.
+*/
+package serumhttp
+
+import (
+	"log"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/serum-errors/go-serum"
+)
+
+// HeaderCode is the name of the response header that carries the error code.
+const HeaderCode = "Serum-Error-Code"
+
+// Responder writes errors as HTTP responses, choosing statuses according to its table of codes.
+// The zero value is ready to use (as is the result of NewResponder), with no codes mapped.
+type Responder struct {
+	// DefaultStatus is the status for errors whose code isn't mapped.
+	// It should be an error status (4xx or 5xx).  If zero, 500 (Internal Server Error) is used.
+	DefaultStatus int
+
+	// JSON configures how the response body is serialized (see serum.ToJSONWith).
+	// The zero value is the same as serum.ToJSON.
+	JSON serum.JSONOptions
+
+	mu       sync.RWMutex
+	exact    map[string]int
+	prefixes []prefixStatus // Sorted longest first, so the first match is the most specific.
+}
+
+type prefixStatus struct {
+	prefix string
+	status int
+}
+
+// NewResponder returns a new Responder with no codes mapped.
+func NewResponder() *Responder {
+	return &Responder{exact: map[string]int{}}
+}
+
+// DefaultResponder is the Responder used by the package-scope functions.
+var DefaultResponder = NewResponder()
+
+// MapCode declares the status to use for errors with exactly the given code.
+// Exact codes take precedence over prefixes.
+//
+// MapCode is meant to be called at init time, or while setting up a server.
+// It panics if the code is already mapped, or if the status is not a valid HTTP status.
+//
+// Errors:
+//
+//   - serumhttp-error-responder-duplicate -- if the code is already mapped.
+//   - serumhttp-error-responder-invalid -- if the status is not an error status (between 400 and 599).
+func (rr *Responder) MapCode(code string, status int) {
+	checkStatus(status)
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	if _, exists := rr.exact[code]; exists {
+		panic(serum.Error("serumhttp-error-responder-duplicate",
+			serum.WithMessageTemplate("a status is already mapped for error code {{code|q}}"),
+			serum.WithDetail("code", code),
+		))
+	}
+	if rr.exact == nil {
+		rr.exact = map[string]int{}
+	}
+	rr.exact[code] = status
+}
+
+// MapPrefix declares the status to use for errors with codes that begin with the given prefix
+// (and that aren't mapped exactly with MapCode).
+// If several prefixes match a code, the longest one is used.
+//
+// MapPrefix is meant to be called at init time, or while setting up a server.
+// It panics if the prefix is already mapped, or if the status is not a valid HTTP status.
+//
+// Errors:
+//
+//   - serumhttp-error-responder-duplicate -- if the prefix is already mapped.
+//   - serumhttp-error-responder-invalid -- if the status is not an error status (between 400 and 599).
+func (rr *Responder) MapPrefix(prefix string, status int) {
+	checkStatus(status)
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	for _, ps := range rr.prefixes {
+		if ps.prefix == prefix {
+			panic(serum.Error("serumhttp-error-responder-duplicate",
+				serum.WithMessageTemplate("a status is already mapped for error code prefix {{prefix|q}}"),
+				serum.WithDetail("prefix", prefix),
+			))
+		}
+	}
+	rr.prefixes = append(rr.prefixes, prefixStatus{prefix, status})
+	sort.SliceStable(rr.prefixes, func(i, j int) bool {
+		return len(rr.prefixes[i].prefix) > len(rr.prefixes[j].prefix)
+	})
+}
+
+func checkStatus(status int) {
+	if status < 400 || status > 599 {
+		panic(serum.Error("serumhttp-error-responder-invalid",
+			serum.WithMessageTemplate("{{status}} is not an http error status (4xx or 5xx)"),
+			serum.WithDetail("status", strconv.Itoa(status)),
+		))
+	}
+}
+
+// Status returns the status that the Responder would use for the error.
+func (rr *Responder) Status(err error) int {
+	code := serum.Code(err)
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+	if status, ok := rr.exact[code]; ok {
+		return status
+	}
+	for _, ps := range rr.prefixes {
+		if strings.HasPrefix(code, ps.prefix) {
+			return ps.status
+		}
+	}
+	if rr.DefaultStatus != 0 {
+		return rr.DefaultStatus
+	}
+	return http.StatusInternalServerError
+}
+
+// WriteError sends the error as the response.
+// The status is chosen as per Status, the "Content-Type" header is "application/json",
+// the "Serum-Error-Code" header is the error's code, and the body is the error's JSON form.
+// For HEAD requests, the body is omitted.
+//
+// Nothing should have been written to the response yet.
+// If the error is nil, nothing is written.
+func (rr *Responder) WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		return
+	}
+	h := w.Header()
+	h.Set("Content-Type", "application/json")
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set(HeaderCode, serum.Code(err))
+	w.WriteHeader(rr.Status(err))
+	if r != nil && r.Method == http.MethodHead {
+		return
+	}
+	body, _ := serum.ToJSONWith(err, rr.JSON)
+	w.Write(append(body, '\n'))
+}
+
+// Handler adapts a function that returns an error into an http.Handler.
+// If the function returns an error, it's sent with WriteError.
+func (rr *Responder) Handler(fn func(w http.ResponseWriter, r *http.Request) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rr.WriteError(w, r, fn(w, r))
+	})
+}
+
+// Recover wraps a handler so that if it panics, an error response is sent, rather than the connection being dropped.
+// The panic (with a stack trace) is logged with the standard library's `log` package, like `net/http` does.
+//
+// The error sent has the code "serumhttp-error-panic"; the panic value is not included, since it may be sensitive.
+// As with WriteError, this is only useful if the handler hadn't yet written anything to the response.
+//
+// Panics with http.ErrAbortHandler are passed through, since they're a deliberate way to abort a response.
+func (rr *Responder) Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			buf := make([]byte, 64<<10)
+			buf = buf[:runtime.Stack(buf, false)]
+			log.Printf("serumhttp: panic serving %s %s: %v\n%s", r.Method, r.URL, v, buf)
+			rr.WriteError(w, r, serum.Error("serumhttp-error-panic",
+				serum.WithMessageLiteral("the server encountered an internal error while handling the request"),
+			))
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// MapCode calls MapCode on the DefaultResponder.
+//
+// Errors:
+//
+//   - serumhttp-error-responder-duplicate -- if the code is already mapped.
+//   - serumhttp-error-responder-invalid -- if the status is not an error status (between 400 and 599).
+func MapCode(code string, status int) {
+	DefaultResponder.MapCode(code, status)
+}
+
+// MapPrefix calls MapPrefix on the DefaultResponder.
+//
+// Errors:
+//
+//   - serumhttp-error-responder-duplicate -- if the prefix is already mapped.
+//   - serumhttp-error-responder-invalid -- if the status is not an error status (between 400 and 599).
+func MapPrefix(prefix string, status int) {
+	DefaultResponder.MapPrefix(prefix, status)
+}
+
+// WriteError sends the error as the response, using the DefaultResponder.
+// See Responder.WriteError for details.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	DefaultResponder.WriteError(w, r, err)
+}
+
+// HandlerFunc adapts a function that returns an error into an http.Handler.
+// If the function returns an error, it's sent with the DefaultResponder's WriteError.
+// (Use Responder.Handler to use a different Responder.)
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// ServeHTTP calls fn(w, r), and sends any error it returns.
+func (fn HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	DefaultResponder.WriteError(w, r, fn(w, r))
+}
+
+// Recover wraps a handler so that panics are turned into error responses, using the DefaultResponder.
+// See Responder.Recover for details.
+func Recover(next http.Handler) http.Handler {
+	return DefaultResponder.Recover(next)
+}
@@ -0,0 +1,149 @@
+package serumhttp_test
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/serum-errors/go-serum"
+	"github.com/serum-errors/go-serum/serumhttp"
+)
+
+func TestStatus(t *testing.T) {
+	rr := serumhttp.NewResponder()
+	rr.MapCode("test-error-notfound", http.StatusNotFound)
+	rr.MapPrefix("test-error-", http.StatusBadRequest)
+	rr.MapPrefix("test-error-auth-", http.StatusForbidden)
+	for _, tr := range []struct {
+		code   string
+		expect int
+	}{
+		{"test-error-notfound", 404},
+		{"test-error-notfoundish", 400},
+		{"test-error-auth-expired", 403},
+		{"test-error-bad", 400},
+		{"other-error", 500},
+	} {
+		if result := rr.Status(serum.Error(tr.code)); result != tr.expect {
+			t.Errorf("for %s: mismatch:\n\tresult: %d\n\texpect: %d", tr.code, result, tr.expect)
+		}
+	}
+	literal := &serumhttp.Responder{DefaultStatus: http.StatusTeapot}
+	literal.MapCode("test-error-notfound", http.StatusNotFound)
+	if result := literal.Status(serum.Error("test-error-notfound")); result != http.StatusNotFound {
+		t.Errorf("a Responder literal should be usable: %d", result)
+	}
+	if result := literal.Status(serum.Error("other-error")); result != http.StatusTeapot {
+		t.Errorf("default status not used: %d", result)
+	}
+
+	rr.DefaultStatus = http.StatusBadGateway
+	if result := rr.Status(serum.Error("other-error")); result != http.StatusBadGateway {
+		t.Errorf("default status not used: %d", result)
+	}
+
+	for _, tr := range []struct {
+		name string
+		fn   func()
+		code string
+	}{
+		{"duplicate code", func() { rr.MapCode("test-error-notfound", 410) }, "serumhttp-error-responder-duplicate"},
+		{"duplicate prefix", func() { rr.MapPrefix("test-error-", 410) }, "serumhttp-error-responder-duplicate"},
+		{"invalid status", func() { rr.MapCode("test-error-x", 42) }, "serumhttp-error-responder-invalid"},
+		{"success status", func() { rr.MapCode("test-error-x", 200) }, "serumhttp-error-responder-invalid"},
+		{"redirect status", func() { rr.MapPrefix("test-error-y", 302) }, "serumhttp-error-responder-invalid"},
+	} {
+		t.Run(tr.name, func(t *testing.T) {
+			defer func() {
+				if code := serum.Code(recover().(error)); code != tr.code {
+					t.Fatalf("unexpected panic code: %s", code)
+				}
+			}()
+			tr.fn()
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	rr := serumhttp.NewResponder()
+	rr.MapCode("test-error-notfound", http.StatusNotFound)
+	handler := rr.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		if r.URL.Path == "/ok" {
+			w.Write([]byte("fine"))
+			return nil
+		}
+		return serum.Error("test-error-notfound", serum.WithMessageTemplate("no {{path}}"), serum.WithDetail("path", r.URL.Path))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/x", nil))
+		if rec.Code != 404 {
+			t.Errorf("unexpected status: %d", rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("unexpected content type: %s", ct)
+		}
+		if code := rec.Header().Get("Serum-Error-Code"); code != "test-error-notfound" {
+			t.Errorf("unexpected code header: %s", code)
+		}
+		expect := `{"code":"test-error-notfound","message":"no /x","details":{"path":"/x"}}` + "\n"
+		if rec.Body.String() != expect {
+			t.Errorf("mismatch:\n\tresult: %s\n\texpect: %s", rec.Body.String(), expect)
+		}
+	})
+
+	t.Run("head", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("HEAD", "/x", nil))
+		if rec.Code != 404 || rec.Body.Len() != 0 {
+			t.Errorf("unexpected response: %d %q", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("no error", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/ok", nil))
+		if rec.Code != 200 || rec.Body.String() != "fine" || rec.Header().Get("Serum-Error-Code") != "" {
+			t.Errorf("unexpected response: %d %q", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("json options", func(t *testing.T) {
+		rr := serumhttp.NewResponder()
+		rr.JSON.OmitCauses = true
+		rec := httptest.NewRecorder()
+		rr.WriteError(rec, httptest.NewRequest("GET", "/", nil), serum.Error("test-error", serum.WithCause(serum.Error("test-error-secret"))))
+		if expect := `{"code":"test-error"}` + "\n"; rec.Body.String() != expect {
+			t.Errorf("mismatch:\n\tresult: %s\n\texpect: %s", rec.Body.String(), expect)
+		}
+	})
+}
+
+func TestRecover(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	handler := serumhttp.Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("secret")
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != 500 || rec.Header().Get("Serum-Error-Code") != "serumhttp-error-panic" {
+		t.Errorf("unexpected response: %d %q", rec.Code, rec.Body.String())
+	}
+
+	t.Run("abort handler", func(t *testing.T) {
+		defer func() {
+			if v := recover(); v != http.ErrAbortHandler {
+				t.Fatalf("expected ErrAbortHandler to pass through, got %v", v)
+			}
+		}()
+		serumhttp.Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	})
+}
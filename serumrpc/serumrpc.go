@@ -0,0 +1,90 @@
+/*
+The serumrpc package helps Serum errors travel over golang's `net/rpc`.
+
+When a `net/rpc` service method returns an error, the server sends only the string from its `Error()` method,
+and the client receives it as an `rpc.ServerError`, so the code and details are lost.
+This package fixes that with a pair of functions:
+on the server side, Wrap an error before returning it from a service method;
+on the client side, use Recover (or Call, which does it for you) to get the structured error back.
+
+	// Server:
+	func (s *Service) Method(args *Args, reply *Reply) error {
+		// ...
+		return serumrpc.Wrap(err)
+	}
+
+	// Client:
+	err := serumrpc.Call(client, "Service.Method", args, &reply)
+	switch serum.Code(err) {
+	// ...
+	}
+
+Errors that weren't wrapped on the server side are passed through unchanged,
+so clients and servers can be upgraded independently.
+
+Importing this package also registers *serum.ErrorValue with `encoding/gob`,
+so that it can be used in interface-typed fields (such as `error`) of rpc arguments and replies.
+*/
+package serumrpc
+
+import (
+	"encoding/gob"
+	"net/rpc"
+	"strings"
+
+	"github.com/serum-errors/go-serum"
+)
+
+func init() {
+	gob.Register(&serum.ErrorValue{})
+}
+
+// marker begins the string of a wrapped error, so that Recover can tell it apart from other error strings.
+const marker = "serum-json:"
+
+// wrapped is the error type produced by Wrap.
+// Its Error() string carries the serialized error;
+// otherwise it has the original error's code, message, and details, for the benefit of any server-side code that inspects it,
+// and it unwraps to the original error (rather than to the original's cause), so that `errors.Is` and `errors.As` still find it.
+type wrapped struct {
+	err error
+}
+
+func (w *wrapped) Error() string        { return marker + serum.ToJSONString(w.err) }
+func (w *wrapped) Code() string         { return serum.Code(w.err) }
+func (w *wrapped) Message() string      { return serum.Message(w.err) }
+func (w *wrapped) Details() [][2]string { return serum.Details(w.err) }
+func (w *wrapped) Unwrap() error        { return w.err }
+
+// Wrap prepares an error to be returned from a `net/rpc` service method,
+// so that the client can recover it with Recover.
+// A nil error is returned as nil.
+func Wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &wrapped{err}
+}
+
+// Recover turns an error returned by a `net/rpc` client call back into the structured error
+// that the server produced with Wrap.
+// The error is rebuilt with serum.FromJSON, so any decoders registered with serum.RegisterDecodable are used.
+//
+// Any other error (including errors that the server didn't Wrap, and errors from the connection itself)
+// is returned unchanged.
+func Recover(err error) error {
+	se, ok := err.(rpc.ServerError)
+	if !ok || !strings.HasPrefix(string(se), marker) {
+		return err
+	}
+	decoded, decodeErr := serum.FromJSON([]byte(strings.TrimPrefix(string(se), marker)))
+	if decodeErr != nil || decoded == nil {
+		return err
+	}
+	return decoded
+}
+
+// Call is the same as `(*rpc.Client).Call`, but passes the resulting error through Recover.
+func Call(client *rpc.Client, serviceMethod string, args interface{}, reply interface{}) error {
+	return Recover(client.Call(serviceMethod, args, reply))
+}
@@ -0,0 +1,100 @@
+package serumrpc_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"net"
+	"net/rpc"
+	"testing"
+
+	"github.com/serum-errors/go-serum"
+	"github.com/serum-errors/go-serum/serumrpc"
+)
+
+type Jobs struct{}
+
+func (Jobs) Find(id string, reply *string) error {
+	if id == "plain" {
+		return errors.New("plain failure")
+	}
+	return serumrpc.Wrap(serum.Error("test-error-jobnotfound",
+		serum.WithMessageTemplate("job {{ID}} not found"),
+		serum.WithDetail("ID", id),
+		serum.WithCause(serum.Error("test-error-storage")),
+	))
+}
+
+type Reply struct {
+	Err error
+}
+
+func (Jobs) Check(id string, reply *Reply) error {
+	reply.Err = serum.Error("test-error-warning", serum.WithDetail("ID", id))
+	return nil
+}
+
+func TestRPC(t *testing.T) {
+	server := rpc.NewServer()
+	if err := server.Register(Jobs{}); err != nil {
+		t.Fatal(err)
+	}
+	serverConn, clientConn := net.Pipe()
+	go server.ServeConn(serverConn)
+	client := rpc.NewClient(clientConn)
+	defer client.Close()
+
+	var reply string
+	err := serumrpc.Call(client, "Jobs.Find", "12", &reply)
+	if serum.Code(err) != "test-error-jobnotfound" || serum.Detail(err, "ID") != "12" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if serum.Code(serum.Cause(err)) != "test-error-storage" {
+		t.Fatalf("unexpected cause: %v", serum.Cause(err))
+	}
+
+	err = serumrpc.Call(client, "Jobs.Find", "plain", &reply)
+	if _, ok := err.(rpc.ServerError); !ok || err.Error() != "plain failure" {
+		t.Fatalf("unwrapped errors should pass through, got %T: %v", err, err)
+	}
+
+	var r Reply
+	if err := client.Call("Jobs.Check", "7", &r); err != nil {
+		t.Fatal(err)
+	}
+	if serum.Code(r.Err) != "test-error-warning" || serum.Detail(r.Err, "ID") != "7" {
+		t.Fatalf("unexpected error in reply: %v", r.Err)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	original := serum.Error("test-error-jobnotfound",
+		serum.WithMessageTemplate("job {{ID}} not found"),
+		serum.WithDetail("ID", "12"),
+	)
+	err := serumrpc.Wrap(original)
+	if serum.Code(err) != "test-error-jobnotfound" || serum.Message(err) != "job 12 not found" || serum.Detail(err, "ID") != "12" {
+		t.Fatalf("wrapped error should have the original's code, message, and details, got: %v", serum.ToJSONString(err))
+	}
+	if !errors.Is(err, original) {
+		t.Fatalf("wrapped error should unwrap to the original")
+	}
+	if serumrpc.Wrap(nil) != nil {
+		t.Fatalf("wrapping nil should give nil")
+	}
+}
+
+func TestGob(t *testing.T) {
+	original := serum.Error("test-error-outer", serum.WithDetail("k", "v"), serum.WithCause(serum.Error("test-error-inner")))
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(original.(*serum.ErrorValue)); err != nil {
+		t.Fatal(err)
+	}
+	var decoded serum.ErrorValue
+	if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+	if a, b := serum.ToJSONString(original), serum.ToJSONString(&decoded); a != b {
+		t.Fatalf("round trip mismatch:\n\toriginal: %s\n\tdecoded:  %s", a, b)
+	}
+}
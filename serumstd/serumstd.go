@@ -0,0 +1,139 @@
+/*
+The serumstd package gives stable Serum codes and details to common errors from the golang standard library.
+
+Without this package, errors like `*fs.PathError` or `io.EOF` get invented "bestguess-golang-" codes
+when they're handled by the serum package, and none of their structured information is kept.
+Calling Install registers code and details resolvers with the serum package
+(see serum.RegisterCodeResolver and serum.RegisterDetailsResolver),
+after which those errors get the codes listed below,
+and their fields are available as details -- in serum.Code, serum.Details, serum.Standardize, serum.ToJSON, and so on.
+
+This is opt-in (rather than built into the serum package) because it depends on a fair number of standard library packages,
+and because it changes the codes seen globally, which is a decision for a program's main package to make.
+
+The codes, and the details they carry, are:
+
+  - golang-error-io-eof -- for io.EOF.
+  - golang-error-io-unexpectedeof -- for io.ErrUnexpectedEOF.
+  - golang-error-fs-notexist -- for fs.ErrNotExist (and os.ErrNotExist, which is the same value).
+  - golang-error-fs-exist -- for fs.ErrExist.
+  - golang-error-fs-permission -- for fs.ErrPermission.
+  - golang-error-context-canceled -- for context.Canceled.
+  - golang-error-context-deadlineexceeded -- for context.DeadlineExceeded.
+  - golang-error-fs-path -- for *fs.PathError; details: "op", "path".
+  - golang-error-os-link -- for *os.LinkError; details: "op", "old", "new".
+  - golang-error-os-syscall -- for *os.SyscallError; details: "syscall".
+  - golang-error-syscall-errno -- for syscall.Errno; details: "errno".
+  - golang-error-net-op -- for *net.OpError; details: "op", "net", "source" (if known), "addr" (if known), "timeout".
+  - golang-error-url -- for *url.Error; details: "op", "url", "timeout".
+  - golang-error-exec-exit -- for *exec.ExitError; details: "exitStatus".
+  - golang-error-json-syntax -- for *json.SyntaxError; details: "offset".
+  - golang-error-strconv-num -- for *strconv.NumError; details: "func", "num".
+
+Only the error itself is adapted; its cause (e.g. the syscall.Errno inside a *fs.PathError) is handled separately, as usual.
+*/
+package serumstd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"syscall"
+
+	"github.com/serum-errors/go-serum"
+)
+
+var installOnce sync.Once
+
+// Install registers the adapters for standard library errors with the serum package.
+// It's safe to call more than once; only the first call has any effect.
+// Typically, it's called once, early in a program's main function.
+func Install() {
+	installOnce.Do(func() {
+		serum.RegisterCodeResolver(Code)
+		serum.RegisterDetailsResolver(Details)
+	})
+}
+
+// Code returns the code for a standard library error, and false if the error isn't one this package knows about.
+// This is the code resolver registered by Install; it can also be used directly.
+func Code(err error) (string, bool) {
+	switch err {
+	case io.EOF:
+		return "golang-error-io-eof", true
+	case io.ErrUnexpectedEOF:
+		return "golang-error-io-unexpectedeof", true
+	case fs.ErrNotExist:
+		return "golang-error-fs-notexist", true
+	case fs.ErrExist:
+		return "golang-error-fs-exist", true
+	case fs.ErrPermission:
+		return "golang-error-fs-permission", true
+	case context.Canceled:
+		return "golang-error-context-canceled", true
+	case context.DeadlineExceeded:
+		return "golang-error-context-deadlineexceeded", true
+	}
+	switch err.(type) {
+	case *fs.PathError:
+		return "golang-error-fs-path", true
+	case *os.LinkError:
+		return "golang-error-os-link", true
+	case *os.SyscallError:
+		return "golang-error-os-syscall", true
+	case syscall.Errno:
+		return "golang-error-syscall-errno", true
+	case *net.OpError:
+		return "golang-error-net-op", true
+	case *url.Error:
+		return "golang-error-url", true
+	case *exec.ExitError:
+		return "golang-error-exec-exit", true
+	case *json.SyntaxError:
+		return "golang-error-json-syntax", true
+	case *strconv.NumError:
+		return "golang-error-strconv-num", true
+	}
+	return "", false
+}
+
+// Details returns the details for a standard library error, and false if the error isn't one this package knows about
+// (or is one that doesn't carry any structured information).
+// This is the details resolver registered by Install; it can also be used directly.
+func Details(err error) ([][2]string, bool) {
+	switch e2 := err.(type) {
+	case *fs.PathError:
+		return [][2]string{{"op", e2.Op}, {"path", e2.Path}}, true
+	case *os.LinkError:
+		return [][2]string{{"op", e2.Op}, {"old", e2.Old}, {"new", e2.New}}, true
+	case *os.SyscallError:
+		return [][2]string{{"syscall", e2.Syscall}}, true
+	case syscall.Errno:
+		return [][2]string{{"errno", strconv.FormatUint(uint64(e2), 10)}}, true
+	case *net.OpError:
+		details := [][2]string{{"op", e2.Op}, {"net", e2.Net}}
+		if e2.Source != nil {
+			details = append(details, [2]string{"source", e2.Source.String()})
+		}
+		if e2.Addr != nil {
+			details = append(details, [2]string{"addr", e2.Addr.String()})
+		}
+		return append(details, [2]string{"timeout", strconv.FormatBool(e2.Timeout())}), true
+	case *url.Error:
+		return [][2]string{{"op", e2.Op}, {"url", e2.URL}, {"timeout", strconv.FormatBool(e2.Timeout())}}, true
+	case *exec.ExitError:
+		return [][2]string{{"exitStatus", strconv.Itoa(e2.ExitCode())}}, true
+	case *json.SyntaxError:
+		return [][2]string{{"offset", strconv.FormatInt(e2.Offset, 10)}}, true
+	case *strconv.NumError:
+		return [][2]string{{"func", e2.Func}, {"num", e2.Num}}, true
+	}
+	return nil, false
+}
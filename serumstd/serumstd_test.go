@@ -0,0 +1,73 @@
+package serumstd_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/serum-errors/go-serum"
+	"github.com/serum-errors/go-serum/serumstd"
+)
+
+func TestAdapters(t *testing.T) {
+	serumstd.Install()
+	serumstd.Install() // Should be harmless.
+
+	_, openErr := os.Open("/definitely/does/not/exist")
+	_, numErr := strconv.Atoi("twelve")
+	var jsonErr error = json.Unmarshal([]byte(`{"a":`), new(interface{}))
+	tt := []struct {
+		err     error
+		code    string
+		details [][2]string
+	}{
+		{io.EOF, "golang-error-io-eof", nil},
+		{os.ErrNotExist, "golang-error-fs-notexist", nil},
+		{context.Canceled, "golang-error-context-canceled", nil},
+		{context.DeadlineExceeded, "golang-error-context-deadlineexceeded", nil},
+		{openErr, "golang-error-fs-path", [][2]string{{"op", "open"}, {"path", "/definitely/does/not/exist"}}},
+		{numErr, "golang-error-strconv-num", [][2]string{{"func", "Atoi"}, {"num", "twelve"}}},
+		{&os.LinkError{Op: "symlink", Old: "a", New: "b", Err: os.ErrExist}, "golang-error-os-link", [][2]string{{"op", "symlink"}, {"old", "a"}, {"new", "b"}}},
+	}
+	if _, ok := jsonErr.(*json.SyntaxError); ok {
+		tt = append(tt, struct {
+			err     error
+			code    string
+			details [][2]string
+		}{jsonErr, "golang-error-json-syntax", [][2]string{{"offset", "5"}}})
+	}
+	for _, test := range tt {
+		if code := serum.Code(test.err); code != test.code {
+			t.Errorf("%v: expected code %q, got %q", test.err, test.code, code)
+		}
+		details := serum.Details(test.err)
+		if len(details) != len(test.details) {
+			t.Errorf("%v: expected details %v, got %v", test.err, test.details, details)
+			continue
+		}
+		for i := range details {
+			if details[i] != test.details[i] {
+				t.Errorf("%v: expected details %v, got %v", test.err, test.details, details)
+			}
+		}
+	}
+
+	t.Run("used by standardize and json", func(t *testing.T) {
+		wrapped := serum.Error("test-error-config", serum.WithCause(openErr))
+		var ev serum.ErrorValue
+		if err := json.Unmarshal([]byte(serum.ToJSONString(wrapped)), &ev); err != nil {
+			t.Fatal(err)
+		}
+		cause := serum.Cause(&ev)
+		if serum.Code(cause) != "golang-error-fs-path" || serum.Detail(cause, "path") != "/definitely/does/not/exist" {
+			t.Fatalf("unexpected cause: %s", serum.ToJSONString(cause))
+		}
+		if !errors.Is(wrapped, serum.CodeSentinel("golang-error-syscall-errno")) {
+			t.Fatalf("the cause's cause should be adapted too: %s", serum.ToJSONString(wrapped))
+		}
+	})
+}
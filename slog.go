@@ -0,0 +1,122 @@
+//go:build go1.21
+
+package serum
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+)
+
+/*
+This file contains integration with the `log/slog` package, which is only available in go1.21 and later.
+
+Errors are logged as a group, with attributes in the same shape as the JSON form:
+"code", "message" (if not empty), each detail (as an attribute named after its key), and "cause" (as a nested group).
+If there are several causes, they're in a "causes" group, with nested groups named "0", "1", and so on.
+Detail keys which would collide with "code", "message", "cause", or "causes" are prefixed with "detail.".
+*/
+
+// LogValue implements `slog.LogValuer`, so that slog logs the error as a group (see SlogAttr), rather than only its Error string.
+func (e *ErrorValue) LogValue() slog.Value {
+	return slogValue(e, 0)
+}
+
+// SlogAttr returns an attribute for logging any error with slog, with the key "error".
+// The value is a group, with the code, message, details, and causes of the error,
+// which are found as ToJSON would (including for non-Serum errors).
+//
+// If the error is nil, the result is an empty attribute, which slog handlers ignore.
+func SlogAttr(err error) slog.Attr {
+	if err == nil {
+		return slog.Attr{}
+	}
+	return slog.Attr{Key: "error", Value: slogValue(err, 0)}
+}
+
+func slogValue(err error, depth int) slog.Value {
+	details := Details(err)
+	attrs := make([]slog.Attr, 0, 3+len(details))
+	attrs = append(attrs, slog.String("code", Code(err)))
+	if msg := serialMessage(err); msg != "" {
+		attrs = append(attrs, slog.String("message", msg))
+	}
+	for _, ent := range details {
+		key := ent[0]
+		switch key {
+		case "code", "message", "cause", "causes":
+			key = "detail." + key
+		}
+		attrs = append(attrs, slog.String(key, ent[1]))
+	}
+	if depth >= walkDepthLimit {
+		return slog.GroupValue(attrs...)
+	}
+	causes := liveCauses(err)
+	switch len(causes) {
+	case 0:
+		// Nothing to add.
+	case 1:
+		attrs = append(attrs, slog.Attr{Key: "cause", Value: slogValue(causes[0], depth+1)})
+	default:
+		group := make([]slog.Attr, len(causes))
+		for i, cause := range causes {
+			group[i] = slog.Attr{Key: strconv.Itoa(i), Value: slogValue(cause, depth+1)}
+		}
+		attrs = append(attrs, slog.Attr{Key: "causes", Value: slog.GroupValue(group...)})
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// NewSlogHandler wraps a slog.Handler so that any attribute whose value is an error
+// is logged as a group (see SlogAttr), rather than only its Error string.
+// This includes attributes within groups, and attributes added with the `With` method of a Logger.
+func NewSlogHandler(h slog.Handler) slog.Handler {
+	return &slogHandler{h}
+}
+
+type slogHandler struct {
+	next slog.Handler
+}
+
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	r2 := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		r2.AddAttrs(expandSlogAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, r2)
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	expanded := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		expanded[i] = expandSlogAttr(a)
+	}
+	return &slogHandler{h.next.WithAttrs(expanded)}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	return &slogHandler{h.next.WithGroup(name)}
+}
+
+func expandSlogAttr(a slog.Attr) slog.Attr {
+	switch a.Value.Kind() {
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok && !isNil(err) {
+			a.Value = slogValue(err, 0)
+		}
+	case slog.KindGroup:
+		group := a.Value.Group()
+		expanded := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			expanded[i] = expandSlogAttr(ga)
+		}
+		a.Value = slog.GroupValue(expanded...)
+	}
+	return a
+}
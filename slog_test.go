@@ -0,0 +1,71 @@
+//go:build go1.21
+
+package serum_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/serum-errors/go-serum"
+)
+
+func TestSlog(t *testing.T) {
+	err := serum.Error("test-error-outer",
+		serum.WithMessageTemplate("job {{ID}} failed"),
+		serum.WithDetail("ID", "12"),
+		serum.WithDetail("code", "x"),
+		serum.WithCause(serum.Error("test-error-inner")),
+		serum.WithCause(errors.New("plain")),
+	)
+	expect := `{"msg":"hi","error":{"code":"test-error-outer","message":"job 12 failed","ID":"12","detail.code":"x","causes":{"0":{"code":"test-error-inner"},"1":{"code":"bestguess-golang-errors-errorString","message":"plain"}}}}`
+
+	for _, tr := range []struct {
+		name string
+		log  func(*slog.Logger)
+	}{
+		{"LogValuer", func(l *slog.Logger) { l.Info("hi", "error", err) }},
+		{"SlogAttr", func(l *slog.Logger) { l.Info("hi", serum.SlogAttr(err)) }},
+	} {
+		t.Run(tr.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tr.log(slog.New(serum.NewSlogHandler(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey) {
+						return slog.Attr{}
+					}
+					return a
+				},
+			}))))
+			if result := strings.TrimSpace(buf.String()); result != expect {
+				t.Fatalf("mismatch:\n\tresult: %s\n\texpect: %s", result, expect)
+			}
+		})
+	}
+
+	t.Run("handler expands other errors", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(serum.NewSlogHandler(slog.NewTextHandler(&buf, nil)))
+		logger.With("base", wrapper{serum.Error("test-error-with")}).WithGroup("g").Info("hi", slog.Group("sub", "err", errors.New("plain")))
+		result := buf.String()
+		for _, want := range []string{"base.code=bestguess-golang-go-serum_test-wrapper", "base.cause.code=test-error-with", "g.sub.err.code=bestguess-golang-errors-errorString", "g.sub.err.message=plain"} {
+			if !strings.Contains(result, want) {
+				t.Errorf("missing %q in %s", want, result)
+			}
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		if a := serum.SlogAttr(nil); !a.Equal(slog.Attr{}) {
+			t.Fatalf("expected an empty attr, got %v", a)
+		}
+	})
+}
+
+// wrapper is an error that isn't a slog.LogValuer, so it's only expanded by the handler.
+type wrapper struct{ err error }
+
+func (w wrapper) Error() string { return w.err.Error() }
+func (w wrapper) Unwrap() error { return w.err }
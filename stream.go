@@ -0,0 +1,107 @@
+package serum
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// StreamEncoder writes errors as newline-delimited JSON: one error per line, in the form produced by ToJSON.
+// This is a common format for log files.
+type StreamEncoder struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewStreamEncoder returns a StreamEncoder that writes to w.
+func NewStreamEncoder(w io.Writer) *StreamEncoder {
+	return &StreamEncoder{w: w}
+}
+
+// Encode writes one error, followed by a newline.
+// Each error is written with a single call to the underlying writer.
+//
+// Errors:
+//
+//   - any error from the writer.
+func (enc *StreamEncoder) Encode(err error) error {
+	enc.buf = AppendJSON(enc.buf[:0], err)
+	enc.buf = append(enc.buf, '\n')
+	_, werr := enc.w.Write(enc.buf)
+	return werr
+}
+
+// StreamDecoder reads newline-delimited JSON errors, as written by StreamEncoder.
+//
+// Blank lines are always skipped.
+// If SkipNonJSON is set, lines which aren't JSON objects are skipped too,
+// which makes it possible to read errors out of log files which also contain other text.
+type StreamDecoder struct {
+	// SkipNonJSON causes lines which aren't JSON objects to be skipped, rather than causing an error.
+	// Lines which are JSON objects, but not valid Serum errors, still cause an error.
+	SkipNonJSON bool
+
+	// Strict causes each error to be parsed with ErrorValue.UnmarshalJSONStrict, rather than ErrorValue.UnmarshalJSON.
+	Strict bool
+
+	r    *bufio.Reader
+	line int
+}
+
+// NewStreamDecoder returns a StreamDecoder that reads from r.
+func NewStreamDecoder(r io.Reader) *StreamDecoder {
+	return &StreamDecoder{r: bufio.NewReader(r)}
+}
+
+// Line returns the line number (starting from 1) of the line most recently read by Decode.
+func (dec *StreamDecoder) Line() int {
+	return dec.line
+}
+
+// Decode reads the next error.
+// At the end of the input, it returns io.EOF.
+//
+// If a line can't be parsed, the returned error says which line it was,
+// and decoding can continue with the next line by calling Decode again.
+//
+// Errors:
+//
+//   - serum-error-stream-decode -- if a line is not a valid Serum error.  The "line" detail has the line number, and the cause says what was wrong.
+//   - io.EOF -- at the end of the input.
+//   - any other error from the reader.
+func (dec *StreamDecoder) Decode() (*ErrorValue, error) {
+	for {
+		line, err := dec.r.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			return nil, err
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		dec.line++
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if dec.SkipNonJSON && (line[0] != '{' || !json.Valid(line)) {
+			continue
+		}
+		var ev ErrorValue
+		var uerr error
+		if dec.Strict {
+			uerr = ev.UnmarshalJSONStrict(line)
+		} else {
+			uerr = ev.UnmarshalJSON(line)
+		}
+		if uerr != nil {
+			return nil, Error("serum-error-stream-decode",
+				WithMessageTemplate("line {{line}} is not a valid serum error"),
+				WithDetail("line", strconv.Itoa(dec.line)),
+				WithCause(uerr),
+			)
+		}
+		return &ev, nil
+	}
+}
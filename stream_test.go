@@ -0,0 +1,77 @@
+package serum_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/serum-errors/go-serum"
+)
+
+func TestStream(t *testing.T) {
+	errs := []error{
+		serum.Error("test-a", serum.WithMessageLiteral("line\nbreaks\nin here")),
+		serum.Error("test-b", serum.WithCause(serum.Error("test-c")), serum.WithDetail("k", "v")),
+	}
+	var buf bytes.Buffer
+	enc := serum.NewStreamEncoder(&buf)
+	for _, err := range errs {
+		if werr := enc.Encode(err); werr != nil {
+			t.Fatal(werr)
+		}
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 2 {
+		t.Fatalf("expected 2 lines, got %d:\n%s", n, buf.String())
+	}
+
+	dec := serum.NewStreamDecoder(&buf)
+	for _, expect := range errs {
+		ev, err := dec.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		eqJson(t, ev, expect, true)
+	}
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+
+	t.Run("mixed logs", func(t *testing.T) {
+		input := "starting up\n\n{\"code\":\"test-a\"}\r\n[warn] something {weird}\n{\"code\":\"test-b\"}"
+		dec := serum.NewStreamDecoder(strings.NewReader(input))
+		dec.SkipNonJSON = true
+		for _, expect := range []struct {
+			code string
+			line int
+		}{{"test-a", 3}, {"test-b", 5}} {
+			ev, err := dec.Decode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ev.Code() != expect.code || dec.Line() != expect.line {
+				t.Fatalf("expected %s on line %d, got %s on line %d", expect.code, expect.line, ev.Code(), dec.Line())
+			}
+		}
+		if _, err := dec.Decode(); err != io.EOF {
+			t.Fatalf("expected EOF, got %v", err)
+		}
+	})
+
+	t.Run("errors report line numbers", func(t *testing.T) {
+		dec := serum.NewStreamDecoder(strings.NewReader("{\"code\":\"test-a\"}\nnot json\n{\"code\":\"test-b\"}\n"))
+		if _, err := dec.Decode(); err != nil {
+			t.Fatal(err)
+		}
+		_, err := dec.Decode()
+		if serum.Code(err) != "serum-error-stream-decode" || serum.Detail(err, "line") != "2" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if serum.Code(serum.Cause(err)) != "serum-error-json-syntax" {
+			t.Fatalf("unexpected cause: %v", serum.Cause(err))
+		}
+		if ev, err := dec.Decode(); err != nil || ev.Code() != "test-b" {
+			t.Fatalf("decoding should continue after an error, got %v, %v", ev, err)
+		}
+	})
+}
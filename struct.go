@@ -1,5 +1,9 @@
 package serum
 
+import (
+	"errors"
+)
+
 // ErrorValue is a concrete type that implements the Serum conventions for errors.
 //
 // It can contain message and details fields in addition to the essential "code" field,
@@ -30,11 +34,32 @@ type ErrorValue struct {
 // but using constructor functions from the go-serum package is often syntactically easier.
 // User code may access these values directly if it's known that the code is handling ErrorValue concretely,
 // but most code is not writen in such a way, and the serum accessor functions are used instead.
+//
+// Causes is a list, because golang errors may have more than one cause
+// (e.g. as produced by `errors.Join`).
+// Most errors have zero or one causes.
+//
+// Extensions holds any fields found when deserializing that aren't part of the Serum spec
+// (e.g. from a newer version of the spec, or some other system's additions),
+// as pairs of the field name and its raw JSON value.
+// They're kept so they can be emitted again, unchanged, when serializing.
+// When serializing, an extension is skipped if its name is one of the spec's own fields
+// ("code", "message", "details", "cause", or "causes"), or if its value isn't valid JSON;
+// so take care if setting this field by hand.
+// Most errors have none.
+//
+// Original is not part of the Serum data model, and is never serialized.
+// When an ErrorValue was made by Standardize from some other error, Original holds that other error,
+// so that it remains available for in-process inspection with `errors.As` and `errors.Is`.
+// It is nil otherwise.
 type Data struct {
 	Code    string
 	Message string
 	Details [][2]string
-	Cause   ErrorInterface
+	Causes  []ErrorInterface
+
+	Extensions [][2]string
+	Original   error
 }
 
 // Code returns the Serum errorcode.  Use the `serum.Code` package function to access this without referring to the concrete type.
@@ -46,15 +71,34 @@ func (e *ErrorValue) Message() string { return e.Data.Message }
 // Details returns the Serum details key-values.  Use the `serum.Details` or `serum.DetailsMap` package function to access this without referring to the concrete type.
 func (e *ErrorValue) Details() [][2]string { return e.Data.Details }
 
-// Unwrap returns the Serum cause.  Use the `serum.Cause` package function, or the golang `errors.Unwrap` function, to access this without referring to the concrete type.
-func (e *ErrorValue) Unwrap() error { return e.Data.Cause }
+// Unwrap returns the Serum causes, following golang's `Unwrap() []error` convention.  Use the `serum.Cause` or `serum.Causes` package function to access this without referring to the concrete type.
+func (e *ErrorValue) Unwrap() []error {
+	if len(e.Data.Causes) == 0 {
+		return nil
+	}
+	causes := make([]error, len(e.Data.Causes))
+	for i, cause := range e.Data.Causes {
+		causes[i] = cause
+	}
+	return causes
+}
 
 // Error implements the golang error interface.  The returned string will contain the code, the message if present, and the string of the cause.  Per Serum convention, it does not include any of the details fields.
 func (e *ErrorValue) Error() string { return SynthesizeString(e) }
 
 // Is implements errors.Is so that it works for non-serum errors
 // This allows non-serum-aware packages to take serum errors if they use errors.Is for error comparisons
+//
+// If the target is a CodeSentinel, only the code is compared.
+// Otherwise, the code, message, and details must all be equal.
+// If the value was produced by Standardize, the original error is also checked, using `errors.Is`.
 func (e *ErrorValue) Is(target error) bool {
+	if s, ok := target.(CodeSentinel); ok {
+		return e.Data.Code == string(s)
+	}
+	if e.Data.Original != nil && errors.Is(e.Data.Original, target) {
+		return true
+	}
 	if e.Data.Code != Code(target) {
 		return false
 	}
@@ -76,3 +120,30 @@ func (e *ErrorValue) Is(target error) bool {
 	// We should not unwrap here because errors.Is handles unwrapping.
 	return true
 }
+
+// As implements errors.As so that, if the value was produced by Standardize,
+// the original error (and its chain) can still be found by its golang type.
+// For example, an `*fs.PathError` given to WithCause can be recovered with `errors.As`,
+// even though the cause is stored as a standardized *ErrorValue.
+func (e *ErrorValue) As(target interface{}) bool {
+	if e.Data.Original == nil {
+		return false
+	}
+	return errors.As(e.Data.Original, target)
+}
+
+// GobEncode implements `encoding/gob.GobEncoder`, so that ErrorValue can be sent with gob (and thus with `net/rpc`).
+// The encoding is the same as MarshalJSON (so any Extensions are kept, and the Original field is not sent).
+func (e *ErrorValue) GobEncode() ([]byte, error) {
+	return ToJSON(e)
+}
+
+// GobDecode implements `encoding/gob.GobDecoder`.  It is the same as UnmarshalJSON.
+//
+// Errors:
+//
+//   - serum-error-json-syntax -- if the input is not valid JSON.
+//   - serum-error-json-invalid -- if a field has the wrong type of value.
+func (e *ErrorValue) GobDecode(b []byte) error {
+	return e.UnmarshalJSON(b)
+}
@@ -1,8 +1,13 @@
 package serum
 
 import (
+	"bytes"
+	"encoding/json"
+	"path"
 	"strconv"
 	"strings"
+	"sync"
+	"unicode/utf8"
 )
 
 /*
@@ -17,8 +22,18 @@ attachments diverging from each other, which is hard to imagine being a good thi
 (Also: the `text/template` system doesn't allow controlling the implementation of lookups,
 which would result in problematic efficiency barriers for our situation.)
 
+Interpolations can be followed by a pipeline of processes, separated by "|" characters,
+such as "{{ID | trunc 8 | q}}".  Processes take the value and return a new value.
+Some processes take an argument, which follows the process name after a space;
+arguments can be quoted (in golang syntax) if they contain spaces or other special characters.
+The processes are logic-free: there's no conditionals, no loops, and no way to refer to other values.
+
+A literal "{{" or "}}" can be written by escaping it with a backslash: \{{ or \}}.
+
 There are no errors.
 Questionably-formed template strings just produce questionably-formed output strings.
+(The ParseTemplate function can be used to check a template strictly, in advance;
+it's meant for use in tests and at init time, not during error handling.)
 Lookups for values that aren't defined just produce the template syntax markers wrapped around the lookup key.
 This is a principled choice: when in the middle of error handling, the last thing you want to do is
 to get stuck debugging an explosive error from a templating system; malformed text is better than nothing.
@@ -31,37 +46,86 @@ we expect this system to be used on data quantities where linear scan is much ch
 */
 
 type parsed struct {
-	literal string // If set: just a literal.
-	interp  string // If set: the variable name.
-	process string // If set along with interp: a process to apply.  Only currently supported value is "q", which means apply quoting.
+	literal string    // If set: just a literal.
+	interp  string    // If set: the variable name.
+	process []process // If set along with interp: processes to apply, in order.
+}
+
+type process struct {
+	name string // The name of the process, e.g. "q" or "trunc".
+	arg  string // The argument to the process, if any, already unquoted.
+	raw  string // The original text of this process invocation, used when reporting a problem.
+}
+
+// parse is the lenient parser, used by WithMessageTemplate and friends.
+// It never fails; see the comments at the top of this file.
+func parse(s string) []parsed {
+	result, _ := parseTemplate(s, false)
+	return result
 }
 
-func parse(s string) (result []parsed) {
+// parseTemplate does the real work of parsing.
+// If strict is false, it never returns an error, and does the best it can with questionably-formed templates.
+// If strict is true, it returns an error for the first questionably-formed thing it finds
+// (but still returns the same parse result as the lenient mode would).
+func parseTemplate(s string, strict bool) (result []parsed, err error) {
+	full := s
+	fail := func(e error) {
+		if strict && err == nil {
+			err = e
+		}
+	}
+	checkLiteral := func(lit string) string {
+		if i := indexUnescaped(lit, "}}"); i >= 0 {
+			fail(errTemplateUnbalanced(full, len(full)-len(s)+i))
+		}
+		return strings.ReplaceAll(lit, "\\}}", "}}")
+	}
 	for {
 		start := strings.Index(s, "{{")
+		if start > 0 && s[start-1] == '\\' { // Escaped: emit literally and keep going.
+			result = append(result, parsed{literal: checkLiteral(s[:start-1]) + "{{"})
+			s = s[start+2:]
+			if s == "" {
+				return
+			}
+			continue
+		}
 		if start < 0 {
-			result = append(result, parsed{literal: s})
+			result = append(result, parsed{literal: checkLiteral(s)})
 			return
 		}
 		end := strings.Index(s[start+2:], "}}")
 		if end < 0 {
-			result = append(result, parsed{literal: s})
+			fail(errTemplateUnbalanced(full, len(full)-len(s)+start))
+			result = append(result, parsed{literal: checkLiteral(s[:start]) + strings.ReplaceAll(s[start:], "\\}}", "}}")})
 			return
 		}
 		if start > 0 {
-			result = append(result, parsed{literal: s[0:start]})
+			result = append(result, parsed{literal: checkLiteral(s[0:start])})
 		}
 		if end > 0 {
 			body := s[start+2 : start+2+end]
-			ss := strings.SplitN(body, "|", 2)
+			if i := strings.Index(body, "{{"); i >= 0 {
+				fail(errTemplateUnbalanced(full, len(full)-len(s)+start+2+i))
+			}
+			ss := splitPipeline(body)
 			name := strings.TrimSpace(ss[0])
-			process := ""
-			if len(ss) == 2 {
-				process = strings.TrimSpace(ss[1])
+			if name == "" {
+				fail(errTemplateEmpty(full, len(full)-len(s)+start))
 			}
-			result = append(result, parsed{interp: name, process: process})
+			var procs []process
+			for _, raw := range ss[1:] {
+				proc := parseProcess(raw)
+				if err := checkProcess(full, proc); err != nil {
+					fail(err)
+				}
+				procs = append(procs, proc)
+			}
+			result = append(result, parsed{interp: name, process: procs})
 		}
 		if end == 0 { // edgecase: if we found "{{}}", treat it like a literal.
+			fail(errTemplateEmpty(full, len(full)-len(s)+start))
 			result = append(result, parsed{literal: s[start : start+4]})
 		}
 		s = s[start+end+4:]
@@ -71,6 +135,90 @@ func parse(s string) (result []parsed) {
 	}
 }
 
+// indexUnescaped is like strings.Index, but skips matches that are preceded by a backslash.
+func indexUnescaped(s, substr string) int {
+	offset := 0
+	for {
+		i := strings.Index(s[offset:], substr)
+		if i < 0 {
+			return -1
+		}
+		if offset+i == 0 || s[offset+i-1] != '\\' {
+			return offset + i
+		}
+		offset += i + len(substr)
+	}
+}
+
+// splitPipeline splits on "|" characters, except for those inside double-quoted strings.
+func splitPipeline(body string) (result []string) {
+	var quoted, escaped bool
+	last := 0
+	for i := 0; i < len(body); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && body[i] == '\\':
+			escaped = true
+		case body[i] == '"':
+			quoted = !quoted
+		case !quoted && body[i] == '|':
+			result = append(result, body[last:i])
+			last = i + 1
+		}
+	}
+	return append(result, body[last:])
+}
+
+func parseProcess(raw string) process {
+	raw = strings.TrimSpace(raw)
+	p := process{name: raw, raw: raw}
+	if i := strings.IndexAny(raw, " \t"); i >= 0 {
+		p.name = raw[:i]
+		p.arg = strings.TrimSpace(raw[i+1:])
+		if unq, err := strconv.Unquote(p.arg); err == nil {
+			p.arg = unq
+		}
+	}
+	return p
+}
+
+// checkProcess returns an error if the process is unknown, or can't make sense of its argument.
+func checkProcess(template string, proc process) error {
+	fn := lookupProcess(proc.name)
+	if fn == nil {
+		return Error("serum-error-template-unknown-process",
+			WithMessageTemplate("template {{template|q}} uses unknown process {{process|q}}"),
+			WithDetail("template", template),
+			WithDetail("process", proc.raw),
+		)
+	}
+	if _, ok := fn("", proc.arg); !ok {
+		return Error("serum-error-template-unknown-process",
+			WithMessageTemplate("template {{template|q}} uses process {{process|q}} with an argument it doesn't accept"),
+			WithDetail("template", template),
+			WithDetail("process", proc.raw),
+		)
+	}
+	return nil
+}
+
+func errTemplateUnbalanced(template string, offset int) error {
+	return Error("serum-error-template-unbalanced",
+		WithMessageTemplate("template {{template|q}} has unbalanced delimiters at offset {{offset}}"),
+		WithDetail("template", template),
+		WithDetail("offset", strconv.Itoa(offset)),
+	)
+}
+
+func errTemplateEmpty(template string, offset int) error {
+	return Error("serum-error-template-empty",
+		WithMessageTemplate("template {{template|q}} has an empty interpolation at offset {{offset}}"),
+		WithDetail("template", template),
+		WithDetail("offset", strconv.Itoa(offset)),
+	)
+}
+
 // Composes a string.
 // Linear lookup into table.  Not expected to be used with large data.
 // Does not bother to reuse strings.Builder buffers; possible target for future optimization, at the cost of synchronization.
@@ -85,18 +233,15 @@ func interpolate(ps []parsed, table [][2]string) string {
 			for _, row := range table {
 				if row[0] == p.interp {
 					match = true
-					emit := row[1]
-					switch p.process {
-					case "": // do nothing
-					case "q": // quote it!
-						emit = strconv.Quote(emit)
-					default: // put something weird back in the output so you can see your typo.
-						emit += "{{?!|" + p.process + "}}"
-					}
-					sb.WriteString(emit)
+					sb.WriteString(applyProcesses(row[1], p.process))
 					break
 				}
 			}
+			if !match && hasDefault(p.process) {
+				// A default process means a missing value is fine; treat it as empty.
+				match = true
+				sb.WriteString(applyProcesses("", p.process))
+			}
 			if !match {
 				sb.WriteString("{{")
 				sb.WriteString(p.interp)
@@ -106,3 +251,220 @@ func interpolate(ps []parsed, table [][2]string) string {
 	}
 	return sb.String()
 }
+
+func hasDefault(procs []process) bool {
+	for _, proc := range procs {
+		if proc.name == "default" {
+			return true
+		}
+	}
+	return false
+}
+
+// applyProcesses runs a value through a pipeline of processes.
+// Any process that's unknown (or given an argument it can't use) is skipped,
+// and something weird is appended to the output so you can see your typo.
+func applyProcesses(value string, procs []process) string {
+	var weird []string
+	for _, proc := range procs {
+		fn := lookupProcess(proc.name)
+		if fn == nil {
+			weird = append(weird, proc.raw)
+			continue
+		}
+		result, ok := fn(value, proc.arg)
+		if !ok {
+			weird = append(weird, proc.raw)
+			continue
+		}
+		value = result
+	}
+	for _, w := range weird {
+		value += "{{?!|" + w + "}}"
+	}
+	return value
+}
+
+// builtinProcesses are the processes that are always available.
+// Each function gets the value and the argument, and returns the new value, and false if it couldn't make sense of the argument.
+var builtinProcesses = map[string]func(value, arg string) (string, bool){
+	"q": func(value, arg string) (string, bool) {
+		return strconv.Quote(value), arg == ""
+	},
+	"default": func(value, arg string) (string, bool) {
+		if value == "" {
+			return arg, true
+		}
+		return value, true
+	},
+	"trunc": func(value, arg string) (string, bool) {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 0 {
+			return value, false
+		}
+		if utf8.RuneCountInString(value) <= n {
+			return value, true
+		}
+		i := 0
+		for n > 0 {
+			_, size := utf8.DecodeRuneInString(value[i:])
+			i += size
+			n--
+		}
+		return value[:i], true
+	},
+	"upper": func(value, arg string) (string, bool) {
+		return strings.ToUpper(value), arg == ""
+	},
+	"lower": func(value, arg string) (string, bool) {
+		return strings.ToLower(value), arg == ""
+	},
+	"json": func(value, arg string) (string, bool) {
+		// Messages are for humans, not for embedding in HTML, so don't escape "<", ">", and "&".
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.Encode(value) // Can't fail for a string.
+		return strings.TrimSuffix(buf.String(), "\n"), arg == ""
+	},
+	"path": func(value, arg string) (string, bool) {
+		if value == "" {
+			return "", arg == ""
+		}
+		return path.Base(value), arg == "" // Slash-separated, so the result doesn't depend on the OS.
+	},
+}
+
+var registeredProcesses = struct {
+	mu    sync.RWMutex
+	procs map[string]func(string) string
+}{procs: map[string]func(string) string{}}
+
+func lookupProcess(name string) func(value, arg string) (string, bool) {
+	if fn, ok := builtinProcesses[name]; ok {
+		return fn
+	}
+	registeredProcesses.mu.RLock()
+	fn, ok := registeredProcesses.procs[name]
+	registeredProcesses.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return func(value, arg string) (string, bool) {
+		return fn(value), arg == ""
+	}
+}
+
+// RegisterTemplateProcess adds a process that can be used in message templates,
+// in addition to the built-in ones ("q", "default", "trunc", "upper", "lower", "json", and "path").
+// Once registered, a process can be used in any template, e.g. "{{ID | myprocess}}".
+//
+// Registered processes don't take arguments.
+// They should be simple, fast, and deterministic, and they must not panic:
+// they run in the middle of error handling.
+//
+// RegisterTemplateProcess is meant to be called at init time.
+// It panics if the name is not a single word, or if a process with that name already exists.
+//
+// Errors:
+//
+//   - serum-error-template-process-invalid -- if the name is not usable.
+//   - serum-error-template-process-duplicate -- if the name is already in use.
+func RegisterTemplateProcess(name string, fn func(string) string) {
+	if name == "" || strings.ContainsAny(name, " \t|{}\"") {
+		panic(Error("serum-error-template-process-invalid",
+			WithMessageTemplate("template process name {{name|q}} is not usable"),
+			WithDetail("name", name),
+		))
+	}
+	registeredProcesses.mu.Lock()
+	defer registeredProcesses.mu.Unlock()
+	_, builtin := builtinProcesses[name]
+	_, registered := registeredProcesses.procs[name]
+	if builtin || registered {
+		panic(Error("serum-error-template-process-duplicate",
+			WithMessageTemplate("template process {{name|q}} already exists"),
+			WithDetail("name", name),
+		))
+	}
+	registeredProcesses.procs[name] = fn
+}
+
+// Template is a parsed message template, as produced by ParseTemplate.
+//
+// Templates have the same syntax as is used by WithMessageTemplate;
+// the difference is that a Template has been strictly checked for well-formedness.
+// A Template can be used to construct errors with the WithTemplate function.
+type Template struct {
+	text   string
+	parsed []parsed
+}
+
+// ParseTemplate parses and checks a message template.
+//
+// Unlike WithMessageTemplate (which never fails, and does its best with questionably-formed templates),
+// ParseTemplate returns an error if the template has unbalanced delimiters, empty interpolations,
+// or uses processes which are unknown (or are given arguments they can't use).
+// This is meant for validating templates in advance: in unit tests, or at init time.
+// Note that processes registered with RegisterTemplateProcess must be registered before
+// templates using them are parsed.
+//
+// A literal "{{" or "}}" can be written by escaping it with a backslash: \{{ or \}}.
+//
+// Errors:
+//
+//   - serum-error-template-unbalanced -- if "{{" and "}}" delimiters don't match up.
+//   - serum-error-template-empty -- if there's an interpolation with no detail key in it, like "{{}}".
+//   - serum-error-template-unknown-process -- if a process is unknown, or is given an argument it doesn't accept.
+func ParseTemplate(s string) (*Template, error) {
+	result, err := parseTemplate(s, true)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{s, result}, nil
+}
+
+// MustParseTemplate is like ParseTemplate, but panics if the template is not well-formed.
+// It's meant for use in package-scope var initializers.
+//
+// Errors:
+//
+//   - serum-error-template-unbalanced -- if "{{" and "}}" delimiters don't match up.
+//   - serum-error-template-empty -- if there's an interpolation with no detail key in it, like "{{}}".
+//   - serum-error-template-unknown-process -- if a process is unknown, or is given an argument it doesn't accept.
+func MustParseTemplate(s string) *Template {
+	t, err := ParseTemplate(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// String returns the original text of the template.
+func (t *Template) String() string { return t.text }
+
+// Keys returns the detail keys referred to by the template, in order of first appearance, without duplicates.
+func (t *Template) Keys() []string {
+	var keys []string
+	for _, p := range t.parsed {
+		if p.interp == "" {
+			continue
+		}
+		seen := false
+		for _, k := range keys {
+			if k == p.interp {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			keys = append(keys, p.interp)
+		}
+	}
+	return keys
+}
+
+// Execute produces a string from the template, looking up values in the given details.
+func (t *Template) Execute(details [][2]string) string {
+	return interpolate(t.parsed, details)
+}
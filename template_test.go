@@ -22,9 +22,16 @@ func TestTemplateParse(t *testing.T) {
 		{"a {{ b }}", []parsed{{literal: "a "}, {interp: "b"}}},
 		{"{{b}} a", []parsed{{interp: "b"}, {literal: " a"}}},
 		{"{{b}}", []parsed{{interp: "b"}}},
-		{"{{b|q}}", []parsed{{interp: "b", process: "q"}}},
-		{"{{b | q}}", []parsed{{interp: "b", process: "q"}}},
-		{"{{ b | q }}", []parsed{{interp: "b", process: "q"}}},
+		{"{{b|q}}", []parsed{{interp: "b", process: []process{{name: "q", raw: "q"}}}}},
+		{"{{b | q}}", []parsed{{interp: "b", process: []process{{name: "q", raw: "q"}}}}},
+		{"{{ b | q }}", []parsed{{interp: "b", process: []process{{name: "q", raw: "q"}}}}},
+		{"{{b | trunc 8 | q}}", []parsed{{interp: "b", process: []process{{name: "trunc", arg: "8", raw: "trunc 8"}, {name: "q", raw: "q"}}}}},
+		{`{{b | default "n/a"}}`, []parsed{{interp: "b", process: []process{{name: "default", arg: "n/a", raw: `default "n/a"`}}}}},
+		{`a \{{b}} c`, []parsed{{literal: "a {{"}, {literal: "b}} c"}}},
+		{`a \{{b\}} c`, []parsed{{literal: "a {{"}, {literal: "b}} c"}}},
+		{`\{{{{b}}`, []parsed{{literal: "{{"}, {interp: "b"}}},
+		{`{{b}}\}}`, []parsed{{interp: "b"}, {literal: "}}"}}},
+		{`{{b | default "a|b"}}`, []parsed{{interp: "b", process: []process{{name: "default", arg: "a|b", raw: `default "a|b"`}}}}},
 	}
 	for _, test := range tt {
 		result := parse(test.template)
@@ -80,3 +87,94 @@ func TestTemplateInterpolate(t *testing.T) {
 		}
 	}
 }
+
+// Processes are registered globally, so this is done once, rather than in the test (which may be run more than once).
+func init() {
+	RegisterTemplateProcess("test-reverse", func(s string) string {
+		rs := []rune(s)
+		for i, j := 0, len(rs)-1; i < j; i, j = i+1, j-1 {
+			rs[i], rs[j] = rs[j], rs[i]
+		}
+		return string(rs)
+	})
+}
+
+func TestTemplateProcesses(t *testing.T) {
+	table := [][2]string{
+		{"id", "0123456789abcdef"},
+		{"name", "Wörld <3"},
+		{"empty", ""},
+		{"file", "/var/lib/thing.db"},
+		{"winfile", `C:\data\thing.db`},
+	}
+	tt := []struct {
+		template string
+		expect   string
+	}{
+		{"{{id|q}}", `"0123456789abcdef"`},
+		{"{{id | trunc 8 | q}}", `"01234567"`},
+		{"{{name | trunc 2}}", "Wö"},
+		{"{{name | trunc 100}}", "Wörld <3"},
+		{"{{name | upper}} {{name | lower}}", "WÖRLD <3 wörld <3"},
+		{"{{name | json}}", `"Wörld <3"`},
+		{"{{file | path}}", "thing.db"},
+		{"{{winfile | path}}", `C:\data\thing.db`},
+		{`{{empty | default "n/a"}}`, "n/a"},
+		{`{{missing | default "n/a" | q}}`, `"n/a"`},
+		{`{{name | default "n/a"}}`, "Wörld <3"},
+		{"{{id | trunc 4 | test-reverse}}", "3210"},
+		{"{{id | nope}}", "0123456789abcdef{{?!|nope}}"},
+		{"{{id | trunc x | q}}", `"0123456789abcdef"{{?!|trunc x}}`},
+		{"{{missing | q}}", "{{missing}}"},
+	}
+	for _, test := range tt {
+		result := interpolate(parse(test.template), table)
+		if result != test.expect {
+			t.Errorf("mismatch:\n\tresult: %s\n\texpect: %s", result, test.expect)
+		}
+	}
+}
+
+func TestParseTemplate(t *testing.T) {
+	tt := []struct {
+		template string
+		errCode  string
+		keys     []string
+	}{
+		{"", "", nil},
+		{"plain", "", nil},
+		{"a {{b}} {{c|q}} {{b}}", "", []string{"b", "c"}},
+		{`literal \{{braces\}} {{b}}`, "", []string{"b"}},
+		{`{{b | default "n/a" | trunc 8 | q}}`, "", []string{"b"}},
+		{"a {{b}", "serum-error-template-unbalanced", nil},
+		{"a {{b}} }}", "serum-error-template-unbalanced", nil},
+		{"a {{ {{b}}", "serum-error-template-unbalanced", nil},
+		{`a \{{b}}`, "serum-error-template-unbalanced", nil},
+		{"a {{}}", "serum-error-template-empty", nil},
+		{"a {{ | q}}", "serum-error-template-empty", nil},
+		{"a {{b | nope}}", "serum-error-template-unknown-process", nil},
+		{"a {{b | trunc x}}", "serum-error-template-unknown-process", nil},
+		{"a {{b | q 1}}", "serum-error-template-unknown-process", nil},
+	}
+	for _, test := range tt {
+		tmpl, err := ParseTemplate(test.template)
+		if Code(err) != test.errCode {
+			t.Errorf("template %q: expected error code %q, got %v", test.template, test.errCode, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if fmt.Sprint(tmpl.Keys()) != fmt.Sprint(test.keys) {
+			t.Errorf("template %q: expected keys %v, got %v", test.template, test.keys, tmpl.Keys())
+		}
+	}
+
+	tmpl := MustParseTemplate(`\{{{{b}}\}}`)
+	if s := tmpl.Execute([][2]string{{"b", "x"}}); s != "{{x}}" {
+		t.Errorf("unexpected output: %s", s)
+	}
+	if s := Message(Error("test", WithTemplate(tmpl), WithDetail("b", "y"))); s != "{{y}}" {
+		t.Errorf("unexpected message: %s", s)
+	}
+}